@@ -93,7 +93,14 @@ func TestBnsClientMock(t *testing.T) {
 }
 
 type BnsClientMock struct {
-	GetResults  map[string]md.AbciQueryResponse
+	GetResults map[string]md.AbciQueryResponse
+	// GetJSON maps a path to a JSON serialized result. It is used for
+	// results that are not ABCI query responses, ie /status.
+	GetJSON map[string]string
+	// PostResults maps query path and data to a response. Responses for
+	// queries pinned to a height are looked up with the path suffixed by
+	// @ and the height (ie /gconf@42) first, falling back to the path
+	// alone.
 	PostResults map[string]map[string]md.AbciQueryResponse
 	Err         error
 }
@@ -105,6 +112,13 @@ func (mock *BnsClientMock) Get(ctx context.Context, path string, dest interface{
 	default:
 	}
 
+	if raw, ok := mock.GetJSON[path]; ok {
+		if err := json.Unmarshal([]byte(raw), dest); err != nil {
+			return err
+		}
+		return mock.Err
+	}
+
 	resp, ok := mock.GetResults[path]
 	if !ok {
 		raw, _ := url.PathUnescape(path)
@@ -128,8 +142,9 @@ func (mock *BnsClientMock) Post(ctx context.Context, data []byte, dest interface
 	}
 
 	type params struct {
-		Path string `json:"path"`
-		Data string `json:"data"`
+		Path   string `json:"path"`
+		Data   string `json:"data"`
+		Height string `json:"height"`
 	}
 
 	var p params
@@ -141,7 +156,10 @@ func (mock *BnsClientMock) Post(ctx context.Context, data []byte, dest interface
 	default:
 	}
 
-	resp, ok := mock.PostResults[p.Path][p.Data]
+	resp, ok := mock.PostResults[p.Path+"@"+p.Height][p.Data]
+	if !ok || p.Height == "" {
+		resp, ok = mock.PostResults[p.Path][p.Data]
+	}
 	if !ok {
 		raw, _ := url.PathUnescape(p.Path)
 		return fmt.Errorf("no result declared in mock for %q %q (%q)", p.Path, p.Data, raw)
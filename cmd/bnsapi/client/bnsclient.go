@@ -13,6 +13,7 @@ import (
 	"github.com/tendermint/tendermint/rpc/lib/types"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 	"strings"
 )
@@ -108,6 +109,26 @@ type jsonResponseError struct {
 type abciQueryParams struct {
 	Path string `json:"path"`
 	Data string `json:"data"`
+	// Height is an integer encoded as a string. When not set, the latest
+	// state is queried.
+	Height string `json:"height,omitempty"`
+}
+
+type heightKey struct{}
+
+// WithHeight returns a context that makes all ABCI queries using it read the
+// state at given block height instead of the latest one.
+func WithHeight(ctx context.Context, height int64) context.Context {
+	return context.WithValue(ctx, heightKey{}, height)
+}
+
+// queryHeight returns the block height set in the context or an empty string
+// if not set.
+func queryHeight(ctx context.Context) string {
+	if h, ok := ctx.Value(heightKey{}).(int64); ok && h > 0 {
+		return fmt.Sprint(h)
+	}
+	return ""
 }
 
 func (e *jsonResponseError) Error() string {
@@ -119,8 +140,9 @@ func (e *jsonResponseError) Error() string {
 
 func ABCIKeyQuery(ctx context.Context, c BnsClient, path string, data []byte, destination *models.KeyModel) error {
 	params := abciQueryParams{
-		Path: path,
-		Data: strings.ToUpper(hex.EncodeToString(data)),
+		Path:   path,
+		Data:   strings.ToUpper(hex.EncodeToString(data)),
+		Height: queryHeight(ctx),
 	}
 
 	p, err := json.Marshal(params)
@@ -162,10 +184,52 @@ func ABCIKeyQuery(ctx context.Context, c BnsClient, path string, data []byte, de
 	return nil
 }
 
+// ABCIRawKeyQuery returns the serialized value stored under given key,
+// together with the block height that the state was read at. A missing key
+// returns a nil value and no error.
+func ABCIRawKeyQuery(ctx context.Context, c BnsClient, path string, data []byte) ([]byte, int64, error) {
+	params := abciQueryParams{
+		Path:   path,
+		Data:   strings.ToUpper(hex.EncodeToString(data)),
+		Height: queryHeight(ctx),
+	}
+
+	p, err := json.Marshal(params)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "param")
+	}
+
+	request := rpctypes.NewRPCRequest(rpctypes.JSONRPCIntID(1), "abci_query", p)
+	r, err := json.Marshal(request)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "response")
+	}
+
+	var abciResponse models.AbciQueryResponse
+	if err := c.Post(ctx, r, &abciResponse); err != nil {
+		return nil, 0, errors.Wrap(err, "response")
+	}
+	height := abciResponse.Response.Height
+
+	if len(abciResponse.Response.Key) == 0 && len(abciResponse.Response.Value) == 0 {
+		return nil, height, nil
+	}
+
+	var values weaveapp.ResultSet
+	if err := values.Unmarshal(abciResponse.Response.Value); err != nil {
+		return nil, 0, errors.Wrap(err, "cannot unmarshal values")
+	}
+	if len(values.Results) == 0 {
+		return nil, height, nil
+	}
+	return values.Results[0], height, nil
+}
+
 func ABCIRangeQuery(ctx context.Context, c BnsClient, path string, data string) ABCIIterator {
 	params := abciQueryParams{
-		Path: path + "?range",
-		Data: strings.ToUpper(hex.EncodeToString([]byte(data))),
+		Path:   path + "?range",
+		Data:   strings.ToUpper(hex.EncodeToString([]byte(data))),
+		Height: queryHeight(ctx),
 	}
 
 	p, err := json.Marshal(params)
@@ -201,8 +265,9 @@ func ABCIRangeQuery(ctx context.Context, c BnsClient, path string, data string)
 
 func ABCIPrefixQuery(ctx context.Context, c BnsClient, path string, prefix []byte) ABCIIterator {
 	params := abciQueryParams{
-		Path: path + "?prefix",
-		Data: strings.ToUpper(hex.EncodeToString(prefix)),
+		Path:   path + "?prefix",
+		Data:   strings.ToUpper(hex.EncodeToString(prefix)),
+		Height: queryHeight(ctx),
 	}
 
 	p, err := json.Marshal(params)
@@ -238,8 +303,9 @@ func ABCIPrefixQuery(ctx context.Context, c BnsClient, path string, prefix []byt
 
 func ABCIKeyQueryIter(ctx context.Context, c BnsClient, path string, data []byte) ABCIIterator {
 	params := abciQueryParams{
-		Path: path,
-		Data: strings.ToUpper(hex.EncodeToString(data)),
+		Path:   path,
+		Data:   strings.ToUpper(hex.EncodeToString(data)),
+		Height: queryHeight(ctx),
 	}
 
 	p, err := json.Marshal(params)
@@ -307,17 +373,56 @@ func (it *resultIterator) Next(model orm.Model) ([]byte, error) {
 
 func ABCIFullRangeQuery(ctx context.Context, bns BnsClient, path, data string) ABCIIterator {
 	return &abciFullIterator{
-		ctx:  ctx,
-		bns:  bns,
-		path: path,
-		it:   ABCIRangeQuery(ctx, bns, path, data),
+		ctx:   ctx,
+		bns:   bns,
+		path:  path,
+		query: func(offset []byte) string { return fmt.Sprintf("%x:", offset) },
+		it:    ABCIRangeQuery(ctx, bns, path, data),
 	}
 }
 
+// ABCIIndexRangeQuery returns an iterator over all entities that an index
+// maps given value to, ie all deposits of a depositor for the
+// /deposits/depositor index. Unlike a single range query, it is not limited
+// to one page of results.
+func ABCIIndexRangeQuery(ctx context.Context, bns BnsClient, path string, value []byte) ABCIIterator {
+	return ABCIIndexRangeQueryFrom(ctx, bns, path, value, nil)
+}
+
+// ABCIIndexRangeQueryFrom returns an iterator like ABCIIndexRangeQuery that
+// starts with the entity of given ID. Entities with a lower ID are skipped.
+func ABCIIndexRangeQueryFrom(ctx context.Context, bns BnsClient, path string, value, offset []byte) ABCIIterator {
+	end := nextValue(value)
+	query := func(offset []byte) string { return fmt.Sprintf("%x:%x:%x", value, offset, end) }
+	return &abciFullIterator{
+		ctx:   ctx,
+		bns:   bns,
+		path:  path,
+		query: query,
+		it:    ABCIRangeQuery(ctx, bns, path, query(offset)),
+	}
+}
+
+// nextValue returns the smallest value that is greater than given one and
+// does not have it as a prefix.
+func nextValue(b []byte) []byte {
+	next := make([]byte, len(b))
+	copy(next, b)
+	if len(next) > 0 && next[len(next)-1] < math.MaxUint8 {
+		next[len(next)-1]++
+	} else {
+		next = append(next, 0)
+	}
+	return next
+}
+
 type abciFullIterator struct {
 	ctx  context.Context
 	bns  BnsClient
 	path string
+	// query returns the range query data for the page that starts with
+	// the entity of given ID.
+	query func(offset []byte) string
 
 	it      ABCIIterator
 	lastKey []byte
@@ -348,7 +453,7 @@ func (fi *abciFullIterator) Next(model orm.Model) ([]byte, error) {
 			break
 		}
 	}
-	fi.it = ABCIRangeQuery(fi.ctx, fi.bns, fi.path, fmt.Sprintf("%x:", id))
+	fi.it = ABCIRangeQuery(fi.ctx, fi.bns, fi.path, fi.query(id))
 
 	key, err := fi.it.Next(model)
 	if err == nil && bytes.Equal(key, fi.lastKey) {
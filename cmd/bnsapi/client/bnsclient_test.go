@@ -3,6 +3,7 @@ package client
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
@@ -15,6 +16,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/iov-one/weave"
@@ -300,6 +302,74 @@ consumeIterator:
 	}
 }
 
+func TestABCIIndexRangeQuery(t *testing.T) {
+	hexData := func(s string) string { return strings.ToUpper(hex.EncodeToString([]byte(s))) }
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Params abciQueryParams `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, "can't read body", http.StatusBadRequest)
+			return
+		}
+		if body.Params.Path != "/myquery/owner?range" {
+			t.Errorf("unexpected path: %q", body.Params.Path)
+			http.Error(w, "not supported", http.StatusNotImplemented)
+			return
+		}
+
+		switch body.Params.Data {
+		case hexData("a1::a2"):
+			writeServerResponse(t, w, [][]byte{
+				[]byte("my:0001"),
+				[]byte("my:0002"),
+			}, []weave.Persistent{
+				&persistentMock{Raw: []byte("1")},
+				&persistentMock{Raw: []byte("2")},
+			})
+		case hexData("a1:30303032:a2"):
+			writeServerResponse(t, w, [][]byte{
+				[]byte("my:0002"), // Filter is inclusive.
+				[]byte("my:0003"),
+			}, []weave.Persistent{
+				&persistentMock{Raw: []byte("2")},
+				&persistentMock{Raw: []byte("3")},
+			})
+		case hexData("a1:30303033:a2"):
+			writeServerResponse(t, w, [][]byte{
+				[]byte("my:0003"), // Filter is inclusive.
+			}, []weave.Persistent{
+				&persistentMock{Raw: []byte("3")},
+			})
+		default:
+			t.Errorf("not supported data: %q", body.Params.Data)
+			http.Error(w, "not supported", http.StatusNotImplemented)
+		}
+	}))
+	defer srv.Close()
+
+	bns := NewHTTPBnsClient(srv.URL)
+	it := ABCIIndexRangeQuery(context.Background(), bns, "/myquery/owner", []byte{0xa1})
+
+	var keys []string
+consumeIterator:
+	for {
+		switch key, err := it.Next(ignoreModel{}); {
+		case err == nil:
+			keys = append(keys, string(key))
+		case errors.ErrIteratorDone.Is(err):
+			break consumeIterator
+		default:
+			t.Fatalf("iterator failed: %s", err)
+		}
+	}
+
+	if want := []string{"my:0001", "my:0002", "my:0003"}; !reflect.DeepEqual(want, keys) {
+		t.Fatalf("want %q keys, got %q", want, keys)
+	}
+}
+
 func TestABCIPrefixQuery(t *testing.T) {
 	// Run a fake Tendermint API server that will answer to only expected
 	// query requests.
@@ -425,3 +495,90 @@ func (m *persistentMock) Unmarshal(raw []byte) error {
 func (m *persistentMock) Marshal() ([]byte, error) {
 	return m.Raw, m.Err
 }
+
+func TestWithHeight(t *testing.T) {
+	// Run a fake Tendermint API server that answers only to queries for
+	// the state at height 42.
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rpc rpctypes.RPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&rpc); err != nil {
+			t.Fatalf("unexpected path: %q", r.URL)
+		}
+
+		var params abciQueryParams
+		if err := json.Unmarshal(rpc.Params, &params); err != nil {
+			t.Fatalf("unexpected path: %q", r.URL)
+		}
+
+		if params.Height != "42" {
+			writeServerResponse(t, w, nil, nil)
+			return
+		}
+		writeServerResponse(t, w, [][]byte{
+			[]byte("0001"),
+		}, []weave.Persistent{
+			&persistentMock{Raw: []byte("1")},
+		})
+	}))
+	defer srv.Close()
+
+	bns := NewHTTPBnsClient(srv.URL)
+
+	if _, err := ABCIRangeQuery(context.Background(), bns, "/myquery", "").Next(ignoreModel{}); !errors.ErrIteratorDone.Is(err) {
+		t.Fatalf("want no result for the latest state, got %v", err)
+	}
+
+	ctx := WithHeight(context.Background(), 42)
+	key, err := ABCIRangeQuery(ctx, bns, "/myquery", "").Next(ignoreModel{})
+	if err != nil {
+		t.Fatalf("want result at height 42, got %v", err)
+	}
+	if !bytes.Equal(key, []byte("0001")) {
+		t.Fatalf("unexpected key: %q", key)
+	}
+}
+
+func TestABCIRawKeyQuery(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rpc rpctypes.RPCRequest
+		if err := json.NewDecoder(r.Body).Decode(&rpc); err != nil {
+			t.Fatalf("cannot decode request: %s", err)
+		}
+		var params abciQueryParams
+		if err := json.Unmarshal(rpc.Params, &params); err != nil {
+			t.Fatalf("cannot decode params: %s", err)
+		}
+
+		k, v := bnsapitest.SerializePairs(t, [][]byte{[]byte("foo")}, []weave.Persistent{&persistentMock{Raw: []byte("bar")}})
+		response := map[string]interface{}{"height": "7"}
+		if params.Data == strings.ToUpper(hex.EncodeToString([]byte("foo"))) {
+			response["key"] = k
+			response["value"] = v
+		}
+		payload := map[string]interface{}{
+			"result": map[string]interface{}{"response": response},
+		}
+		if err := json.NewEncoder(w).Encode(payload); err != nil {
+			t.Fatalf("cannot write response: %s", err)
+		}
+	}))
+	defer srv.Close()
+
+	bns := NewHTTPBnsClient(srv.URL)
+
+	value, height, err := ABCIRawKeyQuery(context.Background(), bns, "/myquery", []byte("foo"))
+	if err != nil {
+		t.Fatalf("query: %s", err)
+	}
+	if !bytes.Equal(value, []byte("bar")) || height != 7 {
+		t.Fatalf("unexpected result: %q at %d", value, height)
+	}
+
+	value, height, err = ABCIRawKeyQuery(context.Background(), bns, "/myquery", []byte("baz"))
+	if err != nil {
+		t.Fatalf("query: %s", err)
+	}
+	if value != nil || height != 7 {
+		t.Fatalf("unexpected result: %q at %d", value, height)
+	}
+}
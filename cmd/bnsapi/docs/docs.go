@@ -1,6 +1,6 @@
 // GENERATED BY THE COMMAND ABOVE; DO NOT EDIT
-// This file was generated by swaggo/swag at
-// 2020-04-07 09:36:19.268982074 +0000 UTC m=+8.400265190
+// This file was generated by swaggo/swag
+
 package docs
 
 import (
@@ -52,8 +52,8 @@ var doc = `{
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Pagination offset",
+                        "type": "string",
+                        "description": "Pagination offset, either an integer or the starname to start from, ex: orkun*neuma",
                         "name": "offset",
                         "in": "query"
                     }
@@ -130,6 +130,116 @@ var doc = `{
                 }
             }
         },
+        "/account/resolve/{starname}/history": {
+            "get": {
+                "description": "Each change lists the events that describe it: registered, transferred, targets_replaced, renewed,\ncertificate_added, certificate_deleted, deleted or updated for any other change. The first\nentry is initial if the starname existed when the indexer started, in which case its\nearlier history is not known. Changes made by scheduled tasks have no transaction hash.\nHistory is recorded by the indexer and is available only if it is enabled.\nIMPORTANT: history is recorded only from the block the indexer started at, configured with\nINDEX_START_HEIGHT and defaulting to the latest block when the index store was created.\nEarlier changes are never backfilled, so a starname registered before that block starts\nwith an initial entry at the start height.",
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Returns all changes of a starname, with the height, time and transaction hash of each change.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "starname ex: orkun*neuma",
+                        "name": "starname",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.accountHistory"
+                        }
+                    },
+                    "404": {},
+                    "500": {},
+                    "501": {},
+                    "503": {}
+                }
+            }
+        },
+        "/address/convert/{value}": {
+            "get": {
+                "description": "Accepts a hex or bech32 (iov/tiov) address, a seq:ext/type/id or cond:ext/type/hexdata\ncondition, a hex, bech32 or base64 public key or a contract reference in the\n\u003ccontract\u003e:\u003cid\u003e form, where contract is one of escrow, multisig, gov, deposit or paychan.\nThe deposit reference takes a term deposit ID, not a deposit contract ID.\nReturns the hex and bech32 representations of the address and the condition if known.",
+                "tags": [
+                    "Status"
+                ],
+                "summary": "Convert an address to all its representations.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address, condition, public key or contract reference. ex: escrow:1",
+                        "name": "value",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.AddressConversion"
+                        }
+                    },
+                    "400": {}
+                }
+            }
+        },
+        "/auth/challenge": {
+            "get": {
+                "description": "The challenge can be used once, until it expires. The message to sign is\n\"bnsapi-auth:\u003cstarname\u003e:\u003cchallenge\u003e\".",
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Issue a challenge that must be signed to verify the ownership of a starname.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.authChallengeResponse"
+                        }
+                    },
+                    "503": {}
+                }
+            }
+        },
+        "/auth/verify": {
+            "post": {
+                "description": "Resolve the owner of a starname (account or username) and check that\nthe given ed25519 public key belongs to that owner and that the signature\nof \"bnsapi-auth:\u003cstarname\u003e:\u003cchallenge\u003e\" is valid. The challenge must be issued\nby /auth/challenge and can be used once, before it expires.\nNo transaction is submitted to the chain. Public key and signature must be hex encoded.",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Verify that a challenge was signed by the owner of a starname.",
+                "parameters": [
+                    {
+                        "description": "starname, challenge, public key and signature",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/handlers.authVerifyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.authVerifyResponse"
+                        }
+                    },
+                    "400": {},
+                    "401": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
         "/blocks/{blockHeight}": {
             "get": {
                 "description": "get block detail by blockHeight",
@@ -174,30 +284,30 @@ var doc = `{
                 }
             }
         },
-        "/escrow/escrows": {
+        "/cash/holders": {
             "get": {
-                "description": "At most one of the query parameters must exist(excluding offset)",
+                "description": "Wallets with an equal balance are ordered by their address.\nThe result is cached, so it may not reflect the latest state.",
                 "tags": [
                     "IOV token"
                 ],
-                "summary": "Returns a list of all the smart contract Escrows.",
+                "summary": "Returns wallets holding the largest balance of a token, largest first.",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Pagination offset",
-                        "name": "offset",
+                        "type": "string",
+                        "description": "Token ticker, default IOV",
+                        "name": "ticker",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Source address",
-                        "name": "source",
+                        "type": "integer",
+                        "description": "Number of holders to return, default 100, at most 1000",
+                        "name": "top",
                         "in": "query"
                     },
                     {
-                        "type": "string",
-                        "description": "Destination address",
-                        "name": "destination",
+                        "type": "integer",
+                        "description": "Block height at which the balances are read",
+                        "name": "height",
                         "in": "query"
                     }
                 ],
@@ -209,74 +319,49 @@ var doc = `{
                         }
                     },
                     "400": {},
-                    "404": {},
                     "500": {}
                 }
             }
         },
-        "/gconf/{extensionName}": {
+        "/cash/supply": {
             "get": {
+                "description": "The result is cached, so it may not reflect the latest state.",
                 "tags": [
-                    "Status"
+                    "IOV token"
                 ],
-                "summary": "Get configuration with extension name",
+                "summary": "Returns the total supply of each token, computed from all wallets.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Extension name",
-                        "name": "extensionName",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Block height at which the supply is computed",
+                        "name": "height",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/gconf.Configuration"
+                            "$ref": "#/definitions/coin.Coins"
                         }
                     },
-                    "404": {},
+                    "400": {},
                     "500": {}
                 }
             }
         },
-        "/gov/proposals": {
+        "/currency/tokens": {
             "get": {
-                "description": "At most one of the query parameters must exist(excluding offset)",
+                "description": "If ticker parameter is provided return the token information of that ticker only.",
                 "tags": [
-                    "Governance"
+                    "IOV token"
                 ],
-                "summary": "Returns a list of x/gov Votes entities.",
+                "summary": "Returns a list of x/currency TokenInfo entities.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Author address",
-                        "name": "author",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Base64 encoded electorate ID",
-                        "name": "electorate",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Base64 encoded Elector ID",
-                        "name": "elector",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Integer Electorate ID",
-                        "name": "electorate_id",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Pagination offset",
-                        "name": "offset",
+                        "description": "Token ticker, ex: IOV",
+                        "name": "ticker",
                         "in": "query"
                     }
                 ],
@@ -287,48 +372,35 @@ var doc = `{
                             "$ref": "#/definitions/handlers.MultipleObjectsResponse"
                         }
                     },
-                    "400": {},
                     "404": {},
                     "500": {}
                 }
             }
         },
-        "/gov/votes": {
+        "/escrow/escrows": {
             "get": {
                 "description": "At most one of the query parameters must exist(excluding offset)",
                 "tags": [
-                    "Governance"
+                    "IOV token"
                 ],
-                "summary": "Returns a list of Votes made on the governance.",
+                "summary": "Returns a list of all the smart contract Escrows.",
                 "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Base64 encoded Proposal ID",
-                        "name": "proposal",
-                        "in": "query"
-                    },
                     {
                         "type": "integer",
-                        "description": "Integer encoded Proposal ID",
-                        "name": "proposal_id",
+                        "description": "Pagination offset",
+                        "name": "offset",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Base64 encoded Elector ID",
-                        "name": "elector",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Integer encoded Elector ID",
-                        "name": "elector_id",
+                        "description": "Source address",
+                        "name": "source",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Pagination offset",
-                        "name": "offset",
+                        "type": "string",
+                        "description": "Destination address",
+                        "name": "destination",
                         "in": "query"
                     }
                 ],
@@ -345,124 +417,163 @@ var doc = `{
                 }
             }
         },
-        "/info/": {
-            "get": {
-                "tags": [
-                    "Status"
-                ],
-                "summary": "Returns information about this instance of ` + "`" + `bnsapi` + "`" + `.",
-                "responses": {
-                    "200": {}
-                }
-            }
-        },
-        "/msgfee/msgfees": {
+        "/escrow/escrows/{escrowID}": {
             "get": {
-                "description": "If msgfee parameter is provided return the queried mesgfee information\notherwise returns all available msgfees",
+                "description": "Status is computed from the escrow balance and timeout. It is one of\nactive (can be released), expired (passed timeout and can be returned to the source)\nor empty (does not hold any funds).",
                 "tags": [
-                    "Message Fee"
+                    "IOV token"
                 ],
-                "summary": "Return message fee information based on message path: username/register_token",
+                "summary": "Returns an escrow together with its address, balance and status.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ex: username/register_token",
-                        "name": "msgfee",
-                        "in": "query"
+                        "type": "integer",
+                        "description": "Escrow ID",
+                        "name": "escrowID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/msgfee.MsgFee"
+                            "$ref": "#/definitions/handlers.escrowDetail"
                         }
                     },
+                    "400": {},
                     "404": {},
                     "500": {}
                 }
             }
         },
-        "/multisig/contracts": {
+        "/export/{collection}": {
             "get": {
-                "description": "At most one of the query parameters must exist(excluding offset)",
+                "description": "Unlike the listing endpoints, the whole collection is returned without pagination.\nThe response is streamed either as newline delimited JSON, one {key, value} object per line,\nor as CSV with the key and one column per top level field. Nested values are serialized as JSON.\nIf the export fails after the response has started, the connection is aborted.\nAvailable collections are accounts, domains, usernames, wallets, escrows, multisig, deposits,\ndepositcontracts, proposals, votes, electorates and electionrules.",
                 "tags": [
-                    "IOV token"
+                    "Export"
                 ],
-                "summary": "Returns a list of all the multisig Contracts.",
+                "summary": "Streams all entities of a collection.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Return objects with keys that start with given prefix",
-                        "name": "prefix",
+                        "description": "Collection name, ex: accounts",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Export format: ndjson (default) or csv",
+                        "name": "format",
                         "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
-                        }
-                    },
+                    "200": {},
+                    "400": {},
                     "404": {},
                     "500": {}
                 }
             }
         },
-        "/nonce/address/{address}": {
+        "/gconf": {
             "get": {
-                "description": "Returns nonce and public key registered for a given address if it was ever used.",
+                "description": "Extensions that have no configuration stored are not included.",
                 "tags": [
-                    "Nonce"
+                    "Status"
                 ],
-                "summary": "Returns nonce based on an address",
+                "summary": "Returns all registered configurations, mapped by the extension name.",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Address to query for nonce. ex: iov1qnpaklxv4n6cam7v99hl0tg0dkmu97sh6007un",
-                        "name": "address",
-                        "in": "path",
-                        "required": true
+                        "type": "integer",
+                        "description": "Block height to read the configurations at, defaults to the latest",
+                        "name": "height",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {},
-                    "404": {},
+                    "400": {},
                     "500": {}
                 }
             }
         },
-        "/nonce/pubkey/{pubKey}": {
+        "/gconf/{extensionName}": {
             "get": {
-                "description": "Returns nonce and public key registered for a given pubkey if it was ever used.",
+                "description": "Configurations of extensions that are not registered are decoded on a best-effort basis,\nwith the protobuf fields keyed by their number.",
                 "tags": [
-                    "Nonce"
+                    "Status"
                 ],
-                "summary": "Returns nonce based on an address",
+                "summary": "Get configuration with extension name",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Public key to query for nonce. ex: 12ee6f581fe55673a1e9e1382a0829e32075a0aa4763c968bc526e1852e78c95",
-                        "name": "pubKey",
+                        "description": "Extension name",
+                        "name": "extensionName",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "200": {},
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/gconf.Configuration"
+                        }
+                    },
                     "404": {},
                     "500": {}
                 }
             }
         },
-        "/termdeposit/contracts": {
+        "/gconf/{extensionName}/history": {
             "get": {
-                "description": "The term deposit Contract are the contract defining the dates until which one can deposit.",
+                "description": "Changes are discovered by bisecting the height range with height-pinned queries,\nso a change that was reverted before the next checked height may not be listed.\nThe node must not have pruned the state of the requested range.",
                 "tags": [
-                    "IOV token"
+                    "Status"
                 ],
-                "summary": "Returns a list of bnsd/x/termdeposit entities.",
+                "summary": "Returns the heights at which an extension configuration changed, together with the changed fields.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Extension name",
+                        "name": "extensionName",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First block height to search from, defaults to 1",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last block height to search to, defaults to the latest",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.gconfHistory"
+                        }
+                    },
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/gov/electorates": {
+            "get": {
+                "description": "Each version of an electorate is a separate entity.",
+                "tags": [
+                    "Governance"
+                ],
+                "summary": "Returns a list of x/gov Electorate entities.",
                 "parameters": [
                     {
                         "type": "integer",
@@ -478,35 +589,98 @@ var doc = `{
                             "$ref": "#/definitions/handlers.MultipleObjectsResponse"
                         }
                     },
+                    "400": {},
+                    "500": {}
+                }
+            }
+        },
+        "/gov/electorates/member/{address}": {
+            "get": {
+                "description": "Only the latest version of each electorate is considered.",
+                "tags": [
+                    "Governance"
+                ],
+                "summary": "Returns all electorates that given address is an elector of, with the elector weight.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Elector address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.electorateMembershipsResponse"
+                        }
+                    },
+                    "400": {},
+                    "500": {}
+                }
+            }
+        },
+        "/gov/electorates/{electorateID}": {
+            "get": {
+                "description": "The ID can be provided with a version (ex: 1/2). Without a version the latest version is returned.",
+                "tags": [
+                    "Governance"
+                ],
+                "summary": "Returns a single x/gov Electorate entity.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Electorate ID, optionally with a version. ex: 1 or 1/2",
+                        "name": "electorateID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.versionedObject"
+                        }
+                    },
+                    "400": {},
                     "404": {},
                     "500": {}
                 }
             }
         },
-        "/termdeposit/deposits": {
+        "/gov/proposals": {
             "get": {
-                "description": "At most one of the query parameters must exist (excluding offset).\nThe query may be filtered by Depositor, in which case it returns all the deposits from the Depositor.\nThe query may be filtered by Deposit Contract, in which case it returns all the deposits from this Contract.\nThe query may be filtered by Contract ID, in which case it returns the deposits from the Deposit Contract with this ID.",
+                "description": "At most one of the query parameters must exist(excluding offset)\nProposal raw option is decoded into the option field, being the message executed if the proposal is accepted.",
                 "tags": [
-                    "IOV token"
+                    "Governance"
                 ],
-                "summary": "Returns a list of bnsd/x/termdeposit Deposit entities (individual deposits).",
+                "summary": "Returns a list of x/gov Votes entities.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Depositor address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex(C1721181E83376EF978AA4A9A38A5E27C08C7BB2)",
-                        "name": "depositor",
+                        "description": "Author address",
+                        "name": "author",
                         "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Base64 encoded ID",
-                        "name": "contract",
+                        "description": "Base64 encoded electorate ID",
+                        "name": "electorate",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base64 encoded Elector ID",
+                        "name": "elector",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "description": "Integer encoded Contract ID",
-                        "name": "contract_id",
+                        "description": "Integer Electorate ID",
+                        "name": "electorate_id",
                         "in": "query"
                     },
                     {
@@ -523,168 +697,1523 @@ var doc = `{
                             "$ref": "#/definitions/handlers.MultipleObjectsResponse"
                         }
                     },
+                    "400": {},
                     "404": {},
                     "500": {}
                 }
             }
         },
-        "/tx/submit": {
-            "post": {
-                "description": "Submit transaction to the blockchain",
-                "consumes": [
-                    "text/plain"
-                ],
+        "/gov/proposals/{proposalID}/tally": {
+            "get": {
+                "description": "Quorum and threshold are taken from the election rule and the total weight from the electorate linked to the proposal.",
                 "tags": [
-                    "Transaction"
+                    "Governance"
                 ],
-                "summary": "Submit transaction",
+                "summary": "Returns the current tally of a proposal and whether it would pass if tallied now.",
                 "parameters": [
                     {
-                        "description": "base64 encoded transaction",
-                        "name": "tx",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "Proposal ID",
+                        "name": "proposalID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/handlers.proposalTally"
                         }
+                    },
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/gov/rules": {
+            "get": {
+                "description": "Each version of an election rule is a separate entity.",
+                "tags": [
+                    "Governance"
+                ],
+                "summary": "Returns a list of x/gov ElectionRule entities.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "200": {},
-                    "404": {}
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
+                        }
+                    },
+                    "400": {},
+                    "500": {}
                 }
             }
         },
-        "/username/owner/{address}": {
+        "/gov/rules/{ruleID}": {
             "get": {
+                "description": "The ID can be provided with a version (ex: 1/2). Without a version the latest version is returned.",
                 "tags": [
-                    "Starname"
+                    "Governance"
                 ],
-                "summary": "Returns the username object with associated info for an owner",
+                "summary": "Returns a single x/gov ElectionRule entity.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Address. example: 04C3DB7CCCACF58EEFCC296FF7AD0F6DB7C2FA17 or iov1qnpaklxv4n6cam7v99hl0tg0dkmu97sh6007un",
-                        "name": "address",
-                        "in": "path"
+                        "description": "Election rule ID, optionally with a version. ex: 1 or 1/2",
+                        "name": "ruleID",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/username.Token"
+                            "$ref": "#/definitions/handlers.versionedObject"
                         }
                     },
+                    "400": {},
                     "404": {},
                     "500": {}
                 }
             }
         },
-        "/username/resolve/{username}": {
+        "/gov/votes": {
             "get": {
+                "description": "At most one of the query parameters must exist(excluding offset)",
                 "tags": [
-                    "Starname"
+                    "Governance"
                 ],
-                "summary": "Returns the username object with associated info for an iov username, like thematrix*iov",
+                "summary": "Returns a list of Votes made on the governance.",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "username. example: thematrix*iov",
-                        "name": "username",
-                        "in": "path"
+                        "description": "Base64 encoded Proposal ID",
+                        "name": "proposal",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Integer encoded Proposal ID",
+                        "name": "proposal_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base64 encoded Elector ID",
+                        "name": "elector",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Integer encoded Elector ID",
+                        "name": "elector_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/username.Token"
+                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
                         }
                     },
+                    "400": {},
                     "404": {},
                     "500": {}
                 }
             }
-        }
-    },
-    "definitions": {
-        "account.Account": {
+        },
+        "/info/": {
+            "get": {
+                "tags": [
+                    "Status"
+                ],
+                "summary": "Returns information about this instance of ` + "`" + `bnsapi` + "`" + `.",
+                "responses": {
+                    "200": {}
+                }
+            }
+        },
+        "/migration/schemas": {
+            "get": {
+                "description": "Packages are ordered by name.",
+                "tags": [
+                    "Status"
+                ],
+                "summary": "Returns the current schema version of every package.",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/handlers.packageSchema"
+                            }
+                        }
+                    },
+                    "500": {}
+                }
+            }
+        },
+        "/msgfee/msgfees": {
+            "get": {
+                "description": "If msgfee parameter is provided return the queried mesgfee information\notherwise returns all available msgfees",
+                "tags": [
+                    "Message Fee"
+                ],
+                "summary": "Return message fee information based on message path: username/register_token",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ex: username/register_token",
+                        "name": "msgfee",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/msgfee.MsgFee"
+                        }
+                    },
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/multisig/contracts": {
+            "get": {
+                "description": "At most one of the query parameters must exist(excluding offset)",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns a list of all the multisig Contracts.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Return objects with keys that start with given prefix",
+                        "name": "prefix",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
+                        }
+                    },
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/multisig/contracts/{contractID}": {
+            "get": {
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns a multisig contract together with its address, balance and participant weight.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Contract ID",
+                        "name": "contractID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.multisigContractDetail"
+                        }
+                    },
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/multisig/participant/{address}": {
+            "get": {
+                "description": "The list is served from a local index that is either refreshed periodically or kept up to date by following new blocks.",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns a list of all multisig contracts that given address participates in.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Participant address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
+                        }
+                    },
+                    "400": {},
+                    "500": {},
+                    "503": {}
+                }
+            }
+        },
+        "/nonce/address/{address}": {
+            "get": {
+                "description": "Returns nonce and public key registered for a given address if it was ever used.",
+                "tags": [
+                    "Nonce"
+                ],
+                "summary": "Returns nonce based on an address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address to query for nonce. ex: iov1qnpaklxv4n6cam7v99hl0tg0dkmu97sh6007un",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/nonce/pubkey/{pubKey}": {
+            "get": {
+                "description": "Returns nonce and public key registered for a given pubkey if it was ever used.\nThe public key can be hex, bech32 or base64 encoded. Only ed25519 keys are\naccepted, because weave verifies signatures with ed25519 keys only. Secp256k1\nkeys are rejected.",
+                "tags": [
+                    "Nonce"
+                ],
+                "summary": "Returns nonce based on an address",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Public key to query for nonce. ex: 12ee6f581fe55673a1e9e1382a0829e32075a0aa4763c968bc526e1852e78c95",
+                        "name": "pubKey",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Public key type, only ed25519 is supported",
+                        "name": "type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {},
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/portfolio/{address}": {
+            "get": {
+                "description": "Holdings are the balance, the nonce, escrows that the address is the source or destination of,\nterm deposits with their projected payout, multisig contracts the address participates in,\nowned domains, accounts and usernames. Each collection is limited to its first 1000 items.",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns all holdings of an address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.portfolio"
+                        }
+                    },
+                    "400": {},
+                    "500": {},
+                    "503": {}
+                }
+            }
+        },
+        "/preregistration/records": {
+            "get": {
+                "description": "If domain parameter is provided return the record of that domain only.",
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Returns a list of bnsd/x/preregistration Record entities, being the reserved domains.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Domain name, ex: neuma",
+                        "name": "domain",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pagination offset, being the domain name to start from",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
+                        }
+                    },
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/termdeposit/contracts": {
+            "get": {
+                "description": "The term deposit Contract are the contract defining the dates until which one can deposit.",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns a list of bnsd/x/termdeposit entities.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
+                        }
+                    },
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/termdeposit/contracts/{contractID}/summary": {
+            "get": {
+                "description": "Not found is returned if the contract does not exist.",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns aggregated totals of all deposits made within a term deposit contract.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Contract ID",
+                        "name": "contractID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.depositSummary"
+                        }
+                    },
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/termdeposit/depositors/{address}/summary": {
+            "get": {
+                "description": "Not found is returned for an address that never made a deposit.",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns aggregated totals of all deposits made by a depositor.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Depositor address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.depositSummary"
+                        }
+                    },
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/termdeposit/deposits": {
+            "get": {
+                "description": "At most one of the query parameters must exist (excluding offset).\nThe query may be filtered by Depositor, in which case it returns all the deposits from the Depositor.\nThe query may be filtered by Deposit Contract, in which case it returns all the deposits from this Contract.\nThe query may be filtered by Contract ID, in which case it returns the deposits from the Deposit Contract with this ID.",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns a list of bnsd/x/termdeposit Deposit entities (individual deposits).",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Depositor address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex(C1721181E83376EF978AA4A9A38A5E27C08C7BB2)",
+                        "name": "depositor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Base64 encoded ID",
+                        "name": "contract",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Integer encoded Contract ID",
+                        "name": "contract_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Pagination offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
+                        }
+                    },
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/termdeposit/deposits/{depositID}": {
+            "get": {
+                "description": "A deposit is releasable once its contract expired, unless it was already released.",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Returns a term deposit together with its expected payout and maturity date.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Deposit ID",
+                        "name": "depositID",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.depositDetail"
+                        }
+                    },
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/termdeposit/quote": {
+            "get": {
+                "description": "The rate is computed using the bonuses of the termdeposit configuration, the same way bnsd does it.",
+                "tags": [
+                    "IOV token"
+                ],
+                "summary": "Simulates a term deposit made now and returns its rate and expected payout.",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Integer encoded Contract ID",
+                        "name": "contract_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Amount of tokens to deposit, ex: 10.5",
+                        "name": "amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ticker of the deposited token, ex: IOV. Deposit contracts do not restrict the token.",
+                        "name": "ticker",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.depositQuote"
+                        }
+                    },
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/tx/submit": {
+            "post": {
+                "description": "Submit transaction to the blockchain",
+                "consumes": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Transaction"
+                ],
+                "summary": "Submit transaction",
+                "parameters": [
+                    {
+                        "description": "base64 encoded transaction",
+                        "name": "tx",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {},
+                    "404": {}
+                }
+            }
+        },
+        "/username/owner/{address}": {
+            "get": {
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Returns the username object with associated info for an owner",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Address. example: 04C3DB7CCCACF58EEFCC296FF7AD0F6DB7C2FA17 or iov1qnpaklxv4n6cam7v99hl0tg0dkmu97sh6007un",
+                        "name": "address",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/username.Token"
+                        }
+                    },
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/username/resolve/{username}": {
+            "get": {
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Returns the username object with associated info for an iov username, like thematrix*iov",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username. example: thematrix*iov",
+                        "name": "username",
+                        "in": "path"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/username.Token"
+                        }
+                    },
+                    "404": {},
+                    "500": {}
+                }
+            }
+        },
+        "/username/reverse": {
+            "get": {
+                "description": "The list is served from a local index that is either refreshed periodically or kept up to date by following new blocks.",
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Returns a list of usernames that point to given blockchain address.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Blockchain ID, ex: iov-mainnet",
+                        "name": "blockchain_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Address on that blockchain",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.MultipleObjectsResponse"
+                        }
+                    },
+                    "400": {},
+                    "500": {},
+                    "503": {}
+                }
+            }
+        },
+        "/username/usernames": {
+            "get": {
+                "description": "The list is either the list of all usernames or all usernames of a given owner.\nIf there are more results, next_offset contains the offset of the next page.",
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Returns a list of bnsd/x/username Token entities.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "The owner address format is either in iov address (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pagination offset, being the username to start from, ex: thematrix*iov",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of results, at most 1000",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.usernamesResponse"
+                        }
+                    },
+                    "400": {},
+                    "500": {}
+                }
+            }
+        },
+        "/username/{username}/account": {
+            "get": {
+                "description": "Either the username or the account is null if it does not exist.",
+                "tags": [
+                    "Starname"
+                ],
+                "summary": "Returns a username together with the account of the same name in the iov domain.",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "username, with or without the *iov suffix. example: thematrix*iov",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.usernameAccount"
+                        }
+                    },
+                    "400": {},
+                    "404": {},
+                    "500": {}
+                }
+            }
+        }
+    },
+    "definitions": {
+        "account.Account": {
+            "type": "object",
+            "properties": {
+                "broker": {
+                    "description": "Broker is a weave address (bech32 or hex) that can be provided by a middleman that helped\nfacilitate the registration transaction. For example, an IOV token holder that registers\na domain in exchange for fiat from a client is a broker. Storing the broker helps identify\nthe contribution of such a party, which allows for automated commission distribution through\nan IOV reward initiative, for example. Must be a weave address that starts with a format or hex\nfor example: bech32:tiov16hzpmhecd65u993lasmexrdlkvhcxtlnf7f4ws.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "certificates": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "integer"
+                        }
+                    }
+                },
+                "domain": {
+                    "description": "Domain references a domain that this account belongs to.",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Metadata"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "owner": {
+                    "description": "Owner is a weave.Address that controls this account. Can be empty.\n\nAn account can be administrated by the domain admin. In addition,\nownership can be assigned to an address to allow another party to manage\nselected account.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "targets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/account.BlockchainAddress"
+                    }
+                },
+                "valid_until": {
+                    "description": "Valid until defines the expiration date for the account. Expired account\ncannot be used or modified. This date is always considered in context of\nthe domain that this account belongs. Expired domain expires all belonging\naccounts as well, event if that account valid until date is not yet due.",
+                    "type": "integer"
+                }
+            }
+        },
+        "account.BlockchainAddress": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "An address on the specified blockchain network. Address is not a\nweave.Address as we cannot know what is the format of an address on the\nchain that this token instance links to. Because we do not know the rules\nto validate an address for any blockchain ID, this is an arbitrary bulk of\ndata.\nIt is more convenient to always use encoded representation of each address\nand store it as a string. Using bytes while compact is not as comfortable\nto use.",
+                    "type": "string"
+                },
+                "blockchain_id": {
+                    "description": "An arbitrary blockchain ID.",
+                    "type": "string"
+                }
+            }
+        },
+        "cash.Set": {
+            "type": "object",
+            "properties": {
+                "coins": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/coin.Coin"
+                    }
+                },
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Metadata"
+                }
+            }
+        },
+        "coin.Coin": {
+            "type": "object",
+            "properties": {
+                "fractional": {
+                    "description": "Billionth of coins. 0 \u003c= abs(fractional) \u003c 10^9\nIf fractional != 0, must have same sign as integer",
+                    "type": "integer"
+                },
+                "ticker": {
+                    "description": "Ticker is 3-4 upper-case letters and\nall Coins of the same currency can be combined",
+                    "type": "string"
+                },
+                "whole": {
+                    "description": "Whole coins, -10^15 \u003c integer \u003c 10^15",
+                    "type": "integer"
+                }
+            }
+        },
+        "coin.Coins": {
+            "type": "array",
+            "items": {
+                "$ref": "#/definitions/coin.Coin"
+            }
+        },
+        "escrow.Escrow": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address of this entity. Set during creation and does not change.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "arbiter": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "destination": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "memo": {
+                    "description": "max length 128 character",
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Metadata"
+                },
+                "source": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "timeout": {
+                    "description": "If unreleased before timeout, escrow will return to source.\nTimeout represents wall clock time as read from the block header. Timeout\nis represented using POSIX time format.\nExpiration time is inclusive meaning that the escrow expires as soon as\nthe current time is equal or greater than timeout value.\nnonexpired: [created, timeout)\nexpired: [timeout, infinity)",
+                    "type": "integer"
+                }
+            }
+        },
+        "gconf.Configuration": {
+            "type": "object"
+        },
+        "gov.Elector": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "The address of the voter.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "weight": {
+                    "description": "Weight defines the power of the participants vote. max value is 65535 (2^16-1).",
+                    "type": "integer"
+                }
+            }
+        },
+        "gov.Fraction": {
+            "type": "object",
+            "properties": {
+                "denominator": {
+                    "description": "The bottom number",
+                    "type": "integer"
+                },
+                "numerator": {
+                    "description": "The top number in a fraction.",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.AddressConversion": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "object",
+                    "$ref": "#/definitions/handlers.AddressForms"
+                },
+                "condition": {
+                    "type": "object",
+                    "$ref": "#/definitions/handlers.ConditionDetails"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "input": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.AddressForms": {
+            "type": "object",
+            "properties": {
+                "bech32_mainnet": {
+                    "type": "string"
+                },
+                "bech32_testnet": {
+                    "type": "string"
+                },
+                "hex": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.ConditionDetails": {
+            "type": "object",
+            "properties": {
+                "condition": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Condition"
+                },
+                "data": {
+                    "type": "string"
+                },
+                "extension": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.MultipleObjectsResponse": {
+            "type": "object",
+            "properties": {
+                "objects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/util.KeyValue"
+                    }
+                }
+            }
+        },
+        "handlers.accountHistory": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.accountHistoryEntry"
+                    }
+                },
+                "indexed_height": {
+                    "description": "IndexedHeight is the height of the last block included in the\nhistory.",
+                    "type": "integer"
+                },
+                "starname": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.accountHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "description": "Account is the state after the change or nil if the account was\ndeleted.",
+                    "type": "object",
+                    "$ref": "#/definitions/account.Account"
+                },
+                "events": {
+                    "description": "Events describe how the account differs from its previous state.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "height": {
+                    "type": "integer"
+                },
+                "previous_owner": {
+                    "description": "PreviousOwner is set when the account was transferred.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "tx_hash": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.authChallengeResponse": {
+            "type": "object",
+            "properties": {
+                "challenge": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.authVerifyRequest": {
+            "type": "object",
+            "properties": {
+                "challenge": {
+                    "type": "string"
+                },
+                "pubkey": {
+                    "type": "string"
+                },
+                "signature": {
+                    "type": "string"
+                },
+                "starname": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.authVerifyResponse": {
+            "type": "object",
+            "properties": {
+                "owner": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "starname": {
+                    "type": "string"
+                },
+                "verified": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.depositDetail": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "description": "Address is the address of the wallet that holds the deposited funds.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "contract": {
+                    "type": "object",
+                    "$ref": "#/definitions/termdeposit.DepositContract"
+                },
+                "deposit": {
+                    "type": "object",
+                    "$ref": "#/definitions/termdeposit.Deposit"
+                },
+                "interest": {
+                    "description": "Interest is the deposit amount multiplied by the deposit rate.",
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coin"
+                },
+                "maturity_date": {
+                    "description": "MaturityDate is the time after which the deposit can be released.",
+                    "type": "integer"
+                },
+                "payout": {
+                    "description": "Payout is the deposit amount together with the interest.",
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coin"
+                },
+                "releasable": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "handlers.depositQuote": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coin"
+                },
+                "contract": {
+                    "type": "object",
+                    "$ref": "#/definitions/termdeposit.DepositContract"
+                },
+                "interest": {
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coin"
+                },
+                "maturity_date": {
+                    "type": "integer"
+                },
+                "payout": {
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coin"
+                },
+                "rate": {
+                    "description": "Rate is the rate a deposit made now would get.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Fraction"
+                }
+            }
+        },
+        "handlers.depositSummary": {
+            "type": "object",
+            "properties": {
+                "average_rate": {
+                    "description": "AverageRate is the deposit rate weighted by the deposit amount, as a\ndecimal number.",
+                    "type": "string"
+                },
+                "calendar": {
+                    "description": "Calendar is the expected payout of locked deposits grouped by the\nmonth of their maturity date.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.monthlyPayout"
+                    }
+                },
+                "count": {
+                    "type": "integer"
+                },
+                "locked_count": {
+                    "type": "integer"
+                },
+                "released_count": {
+                    "type": "integer"
+                },
+                "total_locked": {
+                    "description": "TotalLocked is the sum of all deposits that were not released yet.",
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coins"
+                },
+                "total_released": {
+                    "description": "TotalReleased is the sum of all released deposits.",
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coins"
+                }
+            }
+        },
+        "handlers.electorateMembership": {
+            "type": "object",
+            "properties": {
+                "electorate_id": {
+                    "type": "integer"
+                },
+                "version": {
+                    "description": "Version is the latest version of the electorate.",
+                    "type": "integer"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.electorateMembershipsResponse": {
+            "type": "object",
+            "properties": {
+                "objects": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.electorateMembership"
+                    }
+                }
+            }
+        },
+        "handlers.escrowDetail": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "balance": {
+                    "type": "object",
+                    "$ref": "#/definitions/cash.Set"
+                },
+                "condition": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Condition"
+                },
+                "escrow": {
+                    "type": "object",
+                    "$ref": "#/definitions/escrow.Escrow"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.gconfChange": {
+            "type": "object",
+            "properties": {
+                "configuration": {
+                    "description": "Configuration is the configuration after the change, or null if it\nwas removed.",
+                    "type": "object",
+                    "$ref": "#/definitions/gconf.Configuration"
+                },
+                "diff": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.gconfFieldDiff"
+                    }
+                },
+                "height": {
+                    "description": "Height is the first block height with the new configuration.",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.gconfFieldDiff": {
             "type": "object",
             "properties": {
-                "certificates": {
+                "field": {
+                    "type": "string"
+                },
+                "new": {
+                    "type": "string"
+                },
+                "old": {
+                    "type": "string"
+                }
+            }
+        },
+        "handlers.gconfHistory": {
+            "type": "object",
+            "properties": {
+                "changes": {
                     "type": "array",
                     "items": {
-                        "type": "array",
-                        "items": {
-                            "type": "integer"
-                        }
+                        "$ref": "#/definitions/handlers.gconfChange"
                     }
                 },
-                "domain": {
-                    "description": "Domain references a domain that this account belongs to.",
+                "extension": {
                     "type": "string"
                 },
-                "metadata": {
+                "from": {
+                    "type": "integer"
+                },
+                "initial": {
+                    "description": "Initial is the configuration at the From height, or null if the\nconfiguration was not set yet.",
                     "type": "object",
-                    "$ref": "#/definitions/weave.Metadata"
+                    "$ref": "#/definitions/gconf.Configuration"
                 },
-                "name": {
+                "to": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.monthlyPayout": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "month": {
+                    "description": "Month is in YYYY-MM format, in UTC.",
                     "type": "string"
                 },
-                "owner": {
-                    "description": "Owner is a weave.Address that controls this account. Can be empty.\n\nAn account can be administrated by the domain admin. In addition,\nownership can be assigned to an address to allow another party to manage\nselected account.",
+                "payout": {
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coins"
+                }
+            }
+        },
+        "handlers.multisigContractDetail": {
+            "type": "object",
+            "properties": {
+                "address": {
                     "type": "object",
                     "$ref": "#/definitions/weave.Address"
                 },
-                "targets": {
+                "balance": {
+                    "type": "object",
+                    "$ref": "#/definitions/cash.Set"
+                },
+                "can_activate": {
+                    "description": "CanActivate is true if all participants together reach the\nactivation threshold.",
+                    "type": "boolean"
+                },
+                "can_admin": {
+                    "description": "CanAdmin is true if all participants together reach the admin\nthreshold.",
+                    "type": "boolean"
+                },
+                "condition": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Condition"
+                },
+                "contract": {
+                    "type": "object",
+                    "$ref": "#/definitions/multisig.Contract"
+                },
+                "total_weight": {
+                    "description": "TotalWeight is the combined weight of all participants.",
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.packageSchema": {
+            "type": "object",
+            "properties": {
+                "pkg": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "handlers.portfolio": {
+            "type": "object",
+            "properties": {
+                "accounts": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/account.BlockchainAddress"
+                        "$ref": "#/definitions/util.KeyValue"
                     }
                 },
-                "valid_until": {
-                    "description": "Valid until defines the expiration date for the account. Expired account\ncannot be used or modified. This date is always considered in context of\nthe domain that this account belongs. Expired domain expires all belonging\naccounts as well, event if that account valid until date is not yet due.",
+                "address": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "balance": {
+                    "type": "object",
+                    "$ref": "#/definitions/cash.Set"
+                },
+                "deposits": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/handlers.portfolioDeposit"
+                    }
+                },
+                "domains": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/util.KeyValue"
+                    }
+                },
+                "escrows_destination": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/util.KeyValue"
+                    }
+                },
+                "escrows_source": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/util.KeyValue"
+                    }
+                },
+                "multisig_contracts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/util.KeyValue"
+                    }
+                },
+                "nonce": {
+                    "description": "Nonce is the sequence of the next transaction signed by the\naddress. It is zero if the address never signed a transaction.",
                     "type": "integer"
+                },
+                "usernames": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/util.KeyValue"
+                    }
                 }
             }
         },
-        "account.BlockchainAddress": {
+        "handlers.portfolioDeposit": {
             "type": "object",
             "properties": {
-                "address": {
-                    "description": "An address on the specified blockchain network. Address is not a\nweave.Address as we cannot know what is the format of an address on the\nchain that this token instance links to. Because we do not know the rules\nto validate an address for any blockchain ID, this is an arbitrary bulk of\ndata.\nIt is more convenient to always use encoded representation of each address\nand store it as a string. Using bytes while compact is not as comfortable\nto use.",
-                    "type": "string"
+                "key": {
+                    "type": "object",
+                    "$ref": "#/definitions/util.hexbytes"
                 },
-                "blockchain_id": {
-                    "description": "An arbitrary blockchain ID.",
-                    "type": "string"
+                "payout": {
+                    "description": "Payout is the deposit amount together with the interest.",
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coin"
+                },
+                "value": {
+                    "type": "object",
+                    "$ref": "#/definitions/orm.Model"
                 }
             }
         },
-        "coin.Coin": {
+        "handlers.proposalTally": {
             "type": "object",
             "properties": {
-                "fractional": {
-                    "description": "Billionth of coins. 0 \u003c= abs(fractional) \u003c 10^9\nIf fractional != 0, must have same sign as integer",
-                    "type": "integer"
+                "not_voted": {
+                    "description": "NotVoted are the electors that did not vote yet.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/gov.Elector"
+                    }
                 },
-                "ticker": {
-                    "description": "Ticker is 3-4 upper-case letters and\nall Coins of the same currency can be combined",
+                "quorum": {
+                    "type": "object",
+                    "$ref": "#/definitions/gov.Fraction"
+                },
+                "result": {
                     "type": "string"
                 },
-                "whole": {
-                    "description": "Whole coins, -10^15 \u003c integer \u003c 10^15",
+                "status": {
+                    "type": "string"
+                },
+                "threshold": {
+                    "type": "object",
+                    "$ref": "#/definitions/gov.Fraction"
+                },
+                "time_left": {
+                    "description": "TimeLeft is the number of seconds left until the voting period\nends. It is zero once the voting period ended.",
+                    "type": "integer"
+                },
+                "total_abstain": {
+                    "type": "integer"
+                },
+                "total_electorate_weight": {
+                    "description": "TotalElectorateWeight is the weight of the electorate the proposal\nis voted by.",
+                    "type": "integer"
+                },
+                "total_no": {
+                    "type": "integer"
+                },
+                "total_yes": {
+                    "type": "integer"
+                },
+                "voting_end_time": {
                     "type": "integer"
+                },
+                "would_pass": {
+                    "description": "WouldPass is true if the proposal would be accepted when tallied\nwith the current votes.",
+                    "type": "boolean"
                 }
             }
         },
-        "gconf.Configuration": {
-            "type": "object"
+        "handlers.usernameAccount": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "object",
+                    "$ref": "#/definitions/account.Account"
+                },
+                "username": {
+                    "type": "object",
+                    "$ref": "#/definitions/username.Token"
+                }
+            }
         },
-        "handlers.MultipleObjectsResponse": {
+        "handlers.usernamesResponse": {
             "type": "object",
             "properties": {
+                "next_offset": {
+                    "description": "NextOffset is the offset value to request the next page with. It is\nnot set if there are no more results.",
+                    "type": "string"
+                },
                 "objects": {
                     "type": "array",
                     "items": {
@@ -693,6 +2222,18 @@ var doc = `{
                 }
             }
         },
+        "handlers.versionedObject": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "object",
+                    "$ref": "#/definitions/orm.Model"
+                }
+            }
+        },
         "msgfee.MsgFee": {
             "type": "object",
             "properties": {
@@ -709,9 +2250,106 @@ var doc = `{
                 }
             }
         },
+        "multisig.Contract": {
+            "type": "object",
+            "properties": {
+                "activation_threshold": {
+                    "description": "Activation threshold defines the minimal weight value that must be\nprovided from participants in order to activate the contract. Weight is\ncomputed as the sum of weights of all participating signatures.",
+                    "type": "integer"
+                },
+                "address": {
+                    "description": "Address of this entity. Set during creation and does not change.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "admin_threshold": {
+                    "description": "Admin threshold defines the minimal weight value that must be provided\nfrom participants in order to administrate the contract. Weight is\ncomputed as the sum of weights of all participating signatures.",
+                    "type": "integer"
+                },
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Metadata"
+                },
+                "participants": {
+                    "description": "Participants defines a list of all signatures that are allowed to sign the\ncontract.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/multisig.Participant"
+                    }
+                }
+            }
+        },
+        "multisig.Participant": {
+            "type": "object",
+            "properties": {
+                "signature": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "weight": {
+                    "type": "integer"
+                }
+            }
+        },
         "orm.Model": {
             "type": "object"
         },
+        "termdeposit.Deposit": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "Total amount that was deposited within a contract. Must be IOV tokens.\nThis information is used when computing each depositor reward. It is used\ninstead of looking at the actual wallet state, so that it is not possible\nto increase the value of a deposit wallet only shortly before the\ncomputation.",
+                    "type": "object",
+                    "$ref": "#/definitions/coin.Coin"
+                },
+                "created_at": {
+                    "description": "CreatedAt is set to the wall clock value at the deposit creation time.",
+                    "type": "integer"
+                },
+                "deposit_contract_id": {
+                    "description": "Deposit contract ID that this funds allocation was made with.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "depositor": {
+                    "description": "Payback is an address that locked funds and interest are send back to once\nthe contract expires.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Address"
+                },
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Metadata"
+                },
+                "rate": {
+                    "description": "Pro-rated interest rate as detailed in the Confluence spec.",
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Fraction"
+                },
+                "released": {
+                    "description": "Released flag is used to determin whether the funds locked by this deposit\nwere already released or not.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "termdeposit.DepositContract": {
+            "type": "object",
+            "properties": {
+                "metadata": {
+                    "type": "object",
+                    "$ref": "#/definitions/weave.Metadata"
+                },
+                "valid_since": {
+                    "description": "Valid since defines the beginning of when the contract is active.",
+                    "type": "integer"
+                },
+                "valid_until": {
+                    "description": "An expiration date for this deposit contract. After this deadline, all\ndepositor funds are released and deposit contract is no longer active.",
+                    "type": "integer"
+                }
+            }
+        },
         "username.BlockchainAddress": {
             "type": "object",
             "properties": {
@@ -771,6 +2409,25 @@ var doc = `{
                 "type": "integer"
             }
         },
+        "weave.Condition": {
+            "type": "array",
+            "items": {
+                "type": "integer"
+            }
+        },
+        "weave.Fraction": {
+            "type": "object",
+            "properties": {
+                "denominator": {
+                    "description": "The bottom number",
+                    "type": "integer"
+                },
+                "numerator": {
+                    "description": "The top number in a fraction.",
+                    "type": "integer"
+                }
+            }
+        },
         "weave.Metadata": {
             "type": "object",
             "properties": {
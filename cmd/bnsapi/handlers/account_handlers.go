@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
 	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/indexer"
 	"github.com/iov-one/bns/cmd/bnsapi/models"
 	"github.com/iov-one/bns/cmd/bnsapi/util"
 	"github.com/iov-one/weave"
@@ -10,6 +13,9 @@ import (
 	"github.com/iov-one/weave/errors"
 	"log"
 	"net/http"
+	"reflect"
+	"strings"
+	"time"
 )
 
 type DomainsHandler struct {
@@ -41,13 +47,13 @@ func (h *DomainsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var it client.ABCIIterator
 	if admin := q.Get("admin"); len(admin) > 0 {
-		rawAddr, err := weave.ParseAddress(admin)
+		rawAddr, err := requestAddress(r, admin)
 		if err != nil {
 			JSONErr(w, http.StatusBadRequest, "Admin address must be a valid address value..")
 			return
 		}
 		end := NextKeyValue(rawAddr)
-		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/domains/admin", fmt.Sprintf("%s:%x:%x", admin, offset, end))
+		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/domains/admin", fmt.Sprintf("%s:%x:%x", rawAddr, offset, end))
 	} else {
 		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/domains", fmt.Sprintf("%x:", offset))
 	}
@@ -80,6 +86,9 @@ fetchDomains:
 
 type AccountResolveHandler struct {
 	Bns client.BnsClient
+	// Indexer provides the account history. When nil, history is not
+	// available.
+	Indexer *indexer.Indexer
 }
 
 // AccountResolveHandler godoc
@@ -93,6 +102,10 @@ type AccountResolveHandler struct {
 // @Failure 500
 // @Router /account/resolve/{starname} [get]
 func (h *AccountResolveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/history") {
+		h.serveHistory(w, r)
+		return
+	}
 	accountKey := LastChunk(r.URL.Path)
 	var acc account.Account
 	res := models.KeyModel{
@@ -109,6 +122,170 @@ func (h *AccountResolveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	}
 }
 
+type accountHistory struct {
+	Starname string `json:"starname"`
+	// IndexedHeight is the height of the last block included in the
+	// history.
+	IndexedHeight int64                 `json:"indexed_height"`
+	History       []accountHistoryEntry `json:"history"`
+}
+
+type accountHistoryEntry struct {
+	Height int64     `json:"height"`
+	Time   time.Time `json:"time"`
+	TxHash string    `json:"tx_hash,omitempty"`
+	// Events describe how the account differs from its previous state.
+	Events []string `json:"events"`
+	// PreviousOwner is set when the account was transferred.
+	PreviousOwner weave.Address `json:"previous_owner,omitempty"`
+	// Account is the state after the change or nil if the account was
+	// deleted.
+	Account *account.Account `json:"account,omitempty"`
+}
+
+// Events of an account history.
+const (
+	accountInitial            = "initial"
+	accountRegistered         = "registered"
+	accountDeleted            = "deleted"
+	accountTransferred        = "transferred"
+	accountTargetsReplaced    = "targets_replaced"
+	accountRenewed            = "renewed"
+	accountCertificateAdded   = "certificate_added"
+	accountCertificateDeleted = "certificate_deleted"
+	accountUpdated            = "updated"
+)
+
+// serveHistory godoc
+// @Summary Returns all changes of a starname, with the height, time and transaction hash of each change.
+// @Description Each change lists the events that describe it: registered, transferred, targets_replaced, renewed,
+// @Description certificate_added, certificate_deleted, deleted or updated for any other change. The first
+// @Description entry is initial if the starname existed when the indexer started, in which case its
+// @Description earlier history is not known. Changes made by scheduled tasks have no transaction hash.
+// @Description History is recorded by the indexer and is available only if it is enabled.
+// @Description IMPORTANT: history is recorded only from the block the indexer started at, configured with
+// @Description INDEX_START_HEIGHT and defaulting to the latest block when the index store was created.
+// @Description Earlier changes are never backfilled, so a starname registered before that block starts
+// @Description with an initial entry at the start height.
+// @Param starname path string true "starname ex: orkun*neuma"
+// @Tags Starname
+// @Success 200 {object} handlers.accountHistory
+// @Failure 404
+// @Failure 500
+// @Failure 501
+// @Failure 503
+// @Router /account/resolve/{starname}/history [get]
+func (h *AccountResolveHandler) serveHistory(w http.ResponseWriter, r *http.Request) {
+	if h.Indexer == nil {
+		JSONErr(w, http.StatusNotImplemented, "history is not available without the indexer")
+		return
+	}
+	starname := LastChunk(strings.TrimSuffix(r.URL.Path, "/history"))
+
+	changes, height, err := h.Indexer.History(indexer.AccountJournal, []byte(starname))
+	switch {
+	case err == nil:
+	case errors.ErrState.Is(err):
+		JSONErr(w, http.StatusServiceUnavailable, "history is not indexed yet")
+		return
+	default:
+		log.Printf("account history: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	if len(changes) == 0 {
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+
+	history := make([]accountHistoryEntry, 0, len(changes))
+	var prev *account.Account
+	for _, c := range changes {
+		entry := accountHistoryEntry{
+			Height: c.Height,
+			Time:   c.Time,
+		}
+		if len(c.TxHash) != 0 {
+			entry.TxHash = strings.ToUpper(hex.EncodeToString(c.TxHash))
+		}
+		if c.Value != nil {
+			var acc account.Account
+			if err := acc.Unmarshal(c.Value); err != nil {
+				log.Printf("account history %q at %d: %s", starname, c.Height, err)
+				JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				return
+			}
+			entry.Account = &acc
+		}
+		if c.Initial {
+			entry.Events = []string{accountInitial}
+		} else {
+			entry.Events = accountEvents(prev, entry.Account)
+		}
+		if prev != nil && entry.Account != nil && !prev.Owner.Equals(entry.Account.Owner) {
+			entry.PreviousOwner = prev.Owner
+		}
+		history = append(history, entry)
+		prev = entry.Account
+	}
+
+	JSONResp(w, http.StatusOK, accountHistory{
+		Starname:      starname,
+		IndexedHeight: height,
+		History:       history,
+	})
+}
+
+// accountEvents returns the events that describe how the account changed
+// from the previous state. A nil account does not exist.
+func accountEvents(prev, cur *account.Account) []string {
+	switch {
+	case cur == nil:
+		return []string{accountDeleted}
+	case prev == nil:
+		return []string{accountRegistered}
+	}
+
+	var events []string
+	if !prev.Owner.Equals(cur.Owner) {
+		events = append(events, accountTransferred)
+	}
+	if !reflect.DeepEqual(prev.Targets, cur.Targets) {
+		events = append(events, accountTargetsReplaced)
+	}
+	if cur.ValidUntil > prev.ValidUntil {
+		events = append(events, accountRenewed)
+	}
+	if hasNewCertificate(prev.Certificates, cur.Certificates) {
+		events = append(events, accountCertificateAdded)
+	}
+	if hasNewCertificate(cur.Certificates, prev.Certificates) {
+		events = append(events, accountCertificateDeleted)
+	}
+	if len(events) == 0 {
+		events = append(events, accountUpdated)
+	}
+	return events
+}
+
+// hasNewCertificate returns true if any of the certificates is not present
+// in the old set.
+func hasNewCertificate(old, certificates [][]byte) bool {
+	for _, c := range certificates {
+		found := false
+		for _, o := range old {
+			if bytes.Equal(c, o) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return true
+		}
+	}
+	return false
+}
+
 type AccountsHandler struct {
 	Bns client.BnsClient
 }
@@ -122,7 +299,7 @@ type AccountsHandler struct {
 // @Param starname query string false "Premium Starname ex: *neuma"
 // @Param owner query string false "The owner address format is either in iov address (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)"
 // @Param domain query string false "Query by domain"
-// @Param offset query int false "Pagination offset"
+// @Param offset query string false "Pagination offset, either an integer or the starname to start from, ex: orkun*neuma"
 // @Success 200 {object} handlers.MultipleObjectsResponse
 // @Failure 404
 // @Failure 500
@@ -139,8 +316,13 @@ func (h *AccountsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if q.Get("offset")!= "" {
 		var err error
 		offset, err = ExtractNumericID(q.Get("offset"))
+		if err != nil && strings.Contains(q.Get("offset"), "*") {
+			// Accounts are keyed by the starname, which is a valid
+			// offset as well.
+			offset, err = []byte(q.Get("offset")), nil
+		}
 		if err != nil && !errors.ErrEmpty.Is(err) {
-			JSONErr(w, http.StatusBadRequest, "offset is in wrong format. send integer")
+			JSONErr(w, http.StatusBadRequest, "offset is in wrong format. send integer or starname")
 			return
 		}
 	}
@@ -150,7 +332,7 @@ func (h *AccountsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		end := NextKeyValue([]byte(d))
 		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/accounts/domain", fmt.Sprintf("%x:%x:%x", d, offset, end))
 	} else if o := q.Get("owner"); len(o) > 0 {
-		rawAddr, err := WeaveAddressFromQuery(o)
+		rawAddr, err := requestAddress(r, o)
 		if err != nil {
 			JSONErr(w, http.StatusBadRequest, "Owner address must be a valid address value..")
 			return
@@ -1,13 +1,19 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
 	_ "github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/indexer"
 	"github.com/iov-one/bns/cmd/bnsapi/models"
 	"github.com/iov-one/bns/cmd/bnsapi/util"
 	"github.com/iov-one/weave"
 	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	dbm "github.com/tendermint/tendermint/libs/db"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -53,6 +59,132 @@ func TestAccountAccountDetailHandler(t *testing.T) {
 	}
 }
 
+func TestAccountResolveHistory(t *testing.T) {
+	hexKey := func(s string) string { return strings.ToUpper(hex.EncodeToString([]byte(s))) }
+	tag := func(key, value string) map[string][]byte {
+		return map[string][]byte{"key": []byte(hexKey(key)), "value": []byte(value)}
+	}
+	jsonStr := func(v interface{}) string {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("serialize: %s", err)
+		}
+		return string(raw)
+	}
+	block := func(time string, txs ...[]byte) string {
+		return jsonStr(map[string]interface{}{
+			"block": map[string]interface{}{
+				"header": map[string]string{"time": time},
+				"data":   map[string]interface{}{"txs": txs},
+			},
+		})
+	}
+	alice := weave.NewAddress([]byte("alice"))
+	bob := weave.NewAddress([]byte("bob"))
+	transferTx := []byte("transfer")
+
+	bns := &bnsapitest.BnsClientMock{
+		GetJSON: map[string]string{
+			"/status":         `{"sync_info": {"latest_block_height": "7"}}`,
+			"/block?height=5": block("2020-05-01T10:00:00Z"),
+			"/block?height=6": block("2020-05-01T10:00:05Z", []byte("other"), transferTx),
+			"/block?height=7": block("2020-05-01T10:00:10Z"),
+			"/block_results?height=6": jsonStr(map[string]interface{}{
+				"height": "6",
+				"results": map[string]interface{}{
+					"DeliverTx": []interface{}{
+						map[string]interface{}{"code": 0},
+						map[string]interface{}{"code": 0, "tags": []interface{}{tag("account:foo*bar", "s")}},
+					},
+				},
+			}),
+			"/block_results?height=7": jsonStr(map[string]interface{}{
+				"height": "7",
+				"results": map[string]interface{}{
+					"BeginBlock": map[string]interface{}{"tags": []interface{}{tag("account:foo*bar", "d")}},
+				},
+			}),
+		},
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/accounts?range": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("account:foo*bar")},
+					[]weave.Persistent{&account.Account{Name: "foo", Domain: "bar", Owner: alice}}),
+				hexKey(fmt.Sprintf("%x:", "foo*bar")): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("account:foo*bar")},
+					[]weave.Persistent{&account.Account{Name: "foo", Domain: "bar", Owner: alice}}),
+			},
+			"/accounts@6": {
+				hexKey("foo*bar"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("account:foo*bar")},
+					[]weave.Persistent{&account.Account{
+						Name:    "foo",
+						Domain:  "bar",
+						Owner:   bob,
+						Targets: []account.BlockchainAddress{{BlockchainID: "eth", Address: "0x1"}},
+					}}),
+			},
+		},
+	}
+	ix, err := indexer.New(bns, dbm.NewMemDB(), indexer.Config{
+		Journals:    indexer.DefaultJournals(),
+		StartHeight: 5,
+	})
+	if err != nil {
+		t.Fatalf("indexer: %s", err)
+	}
+	if err := ix.Sync(context.Background()); err != nil {
+		t.Fatalf("indexer sync: %s", err)
+	}
+
+	h := AccountResolveHandler{Bns: bns, Indexer: ix}
+
+	r, _ := http.NewRequest("GET", "/account/resolve/foo*bar/history", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("failed response: %d %s", w.Code, w.Body)
+	}
+
+	var got accountHistory
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if got.Starname != "foo*bar" || got.IndexedHeight != 7 {
+		t.Fatalf("unexpected response: %+v", got)
+	}
+	txHash := sha256.Sum256(transferTx)
+	want := []struct {
+		height        int64
+		txHash        string
+		events        string
+		previousOwner weave.Address
+	}{
+		{height: 5, events: "initial"},
+		{height: 6, txHash: strings.ToUpper(hex.EncodeToString(txHash[:])), events: "transferred,targets_replaced", previousOwner: alice},
+		{height: 7, events: "deleted"},
+	}
+	if len(got.History) != len(want) {
+		t.Fatalf("want %d entries, got %+v", len(want), got.History)
+	}
+	for i, w := range want {
+		e := got.History[i]
+		if e.Height != w.height || e.TxHash != w.txHash || strings.Join(e.Events, ",") != w.events || !e.PreviousOwner.Equals(w.previousOwner) {
+			t.Errorf("unexpected entry %d: %+v", i, e)
+		}
+	}
+	if got.History[2].Account != nil {
+		t.Error("deleted account must not be returned")
+	}
+
+	h = AccountResolveHandler{Bns: bns}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotImplemented {
+		t.Fatalf("want history to be not available without the indexer, got %d", w.Code)
+	}
+}
+
 func TestAccountAccountssHandler(t *testing.T) {
 	bns := &bnsapitest.BnsClientMock{
 		PostResults: map[string]map[string]models.AbciQueryResponse{
@@ -104,6 +236,31 @@ func TestAccountAccountssHandlerOffsetAndFilter(t *testing.T) {
 	bnsapitest.AssertAPIResponse(t, w, []util.KeyValue{})
 }
 
+func TestAccountAccountsHandlerStarnameOffset(t *testing.T) {
+	offset := strings.ToUpper(hex.EncodeToString([]byte(fmt.Sprintf("%x:", "alice*neuma"))))
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/accounts?range": {
+				offset: bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("alice*neuma")},
+					[]weave.Persistent{&account.Account{Name: "alice", Domain: "neuma"}}),
+			},
+		},
+	}
+	h := AccountsHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/?offset=alice*neuma", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	bnsapitest.AssertAPIResponse(t, w, []util.KeyValue{
+		{
+			Key:   []byte("alice*neuma"),
+			Value: &account.Account{Name: "alice", Domain: "neuma"},
+		},
+	})
+}
+
 func TestAccountDomainsHandler(t *testing.T) {
 	bns := &bnsapitest.BnsClientMock{
 		PostResults: map[string]map[string]models.AbciQueryResponse{
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// contractConditions maps an entity name to the extension and type of the
+// condition that the entity address is derived from. The condition data is
+// always the big endian encoded entity ID. Term deposits are addressed by the
+// deposit ID, not by the ID of the deposit contract.
+var contractConditions = map[string][2]string{
+	"escrow":   {"escrow", "seq"},
+	"multisig": {"multisig", "usage"},
+	"gov":      {"gov", "rule"},
+	"deposit":  {"deposit", "seq"},
+	"paychan":  {"paychan", "seq"},
+}
+
+type AddressConvertHandler struct{}
+
+type AddressConversion struct {
+	Input     string            `json:"input"`
+	Format    string            `json:"format"`
+	Address   AddressForms      `json:"address"`
+	Condition *ConditionDetails `json:"condition,omitempty"`
+}
+
+type ConditionDetails struct {
+	Condition weave.Condition `json:"condition"`
+	Extension string          `json:"extension"`
+	Type      string          `json:"type"`
+	Data      string          `json:"data"`
+}
+
+// AddressConvertHandler godoc
+// @Summary Convert an address to all its representations.
+// @Description Accepts a hex or bech32 (iov/tiov) address, a seq:ext/type/id or cond:ext/type/hexdata
+// @Description condition, a hex, bech32 or base64 public key or a contract reference in the
+// @Description <contract>:<id> form, where contract is one of escrow, multisig, gov, deposit or paychan.
+// @Description The deposit reference takes a term deposit ID, not a deposit contract ID.
+// @Description Returns the hex and bech32 representations of the address and the condition if known.
+// @Tags Status
+// @Param value path string true "Address, condition, public key or contract reference. ex: escrow:1"
+// @Success 200 {object} handlers.AddressConversion
+// @Failure 400
+// @Router /address/convert/{value} [get]
+func (h *AddressConvertHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	value := PathAfter(r.URL.Path, "/convert/")
+	if value == "" {
+		JSONErr(w, http.StatusBadRequest, "value to convert must be provided")
+		return
+	}
+	conv, err := ConvertAddress(value)
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	JSONResp(w, http.StatusOK, conv)
+}
+
+// ConvertAddress parses given value in any of the supported address formats
+// and returns all known representations of it.
+func ConvertAddress(value string) (*AddressConversion, error) {
+	conv := AddressConversion{Input: value}
+
+	var cond weave.Condition
+	chunks := strings.SplitN(value, ":", 2)
+	if ext, ok := contractConditions[chunks[0]]; ok && len(chunks) == 2 {
+		id, err := strconv.ParseUint(chunks[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInput, "contract ID must be an integer")
+		}
+		conv.Format = "contract"
+		cond = weave.NewCondition(ext[0], ext[1], EncodeSequence(id))
+	} else if chunks[0] == "seq" || chunks[0] == "cond" {
+		if len(chunks) != 2 {
+			return nil, errors.Wrap(errors.ErrInput, "condition must be provided")
+		}
+		c, err := parseCondition(chunks[0], chunks[1])
+		if err != nil {
+			return nil, err
+		}
+		conv.Format = "condition"
+		cond = c
+	} else if addr, err := WeaveAddressFromQuery(value); err == nil && len(addr) != 0 {
+		conv.Format = "address"
+		conv.Address = NewAddressForms(addr)
+		return &conv, nil
+	} else if raw, err := DecodePubKey(value); err == nil {
+		if cond, err = PubKeyCondition("", raw); err != nil {
+			return nil, errors.Wrap(errors.ErrInput, "value is neither an address nor a public key")
+		}
+		conv.Format = "pubkey"
+	} else {
+		return nil, errors.Wrap(errors.ErrInput, "unknown address format")
+	}
+
+	if err := cond.Validate(); err != nil {
+		return nil, err
+	}
+	ext, typ, data, _ := cond.Parse()
+	conv.Address = NewAddressForms(cond.Address())
+	conv.Condition = &ConditionDetails{
+		Condition: cond,
+		Extension: ext,
+		Type:      typ,
+		Data:      strings.ToUpper(hex.EncodeToString(data)),
+	}
+	return &conv, nil
+}
+
+// parseCondition decodes a condition given in the seq:ext/type/id or
+// cond:ext/type/hexdata form, the same way weave.ParseAddress does.
+func parseCondition(format, enc string) (weave.Condition, error) {
+	if format == "cond" {
+		var cond weave.Condition
+		raw, err := json.Marshal(enc)
+		if err != nil {
+			return nil, errors.Wrap(err, "serialize")
+		}
+		if err := cond.UnmarshalJSON(raw); err != nil {
+			return nil, errors.Wrap(errors.ErrInput, "invalid condition format")
+		}
+		return cond, nil
+	}
+
+	chunks := strings.Split(enc, "/")
+	if len(chunks) != 3 {
+		return nil, errors.Wrap(errors.ErrInput, "invalid condition format")
+	}
+	seq, err := strconv.ParseUint(chunks[2], 10, 64)
+	if err != nil {
+		return nil, errors.Wrap(errors.ErrInput, "sequence number must be an integer")
+	}
+	return weave.NewCondition(chunks[0], chunks[1], EncodeSequence(seq)), nil
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/iov-one/weave"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAddressConvertHandler(t *testing.T) {
+	escrowAddr := weave.NewCondition("escrow", "seq", EncodeSequence(1)).Address()
+
+	cases := map[string]struct {
+		path       string
+		wantCode   int
+		wantFormat string
+		wantHex    string
+		wantCond   bool
+	}{
+		"hex address": {
+			path:       "/address/convert/" + escrowAddr.String(),
+			wantCode:   http.StatusOK,
+			wantFormat: "address",
+			wantHex:    escrowAddr.String(),
+		},
+		"bech32 address": {
+			path:       "/address/convert/" + NewAddressForms(escrowAddr).Bech32Testnet,
+			wantCode:   http.StatusOK,
+			wantFormat: "address",
+			wantHex:    escrowAddr.String(),
+		},
+		"escrow contract": {
+			path:       "/address/convert/escrow:1",
+			wantCode:   http.StatusOK,
+			wantFormat: "contract",
+			wantHex:    escrowAddr.String(),
+			wantCond:   true,
+		},
+		"sequence condition": {
+			path:       "/address/convert/seq:escrow/seq/1",
+			wantCode:   http.StatusOK,
+			wantFormat: "condition",
+			wantHex:    escrowAddr.String(),
+			wantCond:   true,
+		},
+		"condition": {
+			path:       "/address/convert/cond:escrow/seq/0000000000000001",
+			wantCode:   http.StatusOK,
+			wantFormat: "condition",
+			wantHex:    escrowAddr.String(),
+			wantCond:   true,
+		},
+		"deposit": {
+			path:       "/address/convert/deposit:1",
+			wantCode:   http.StatusOK,
+			wantFormat: "contract",
+			wantHex:    weave.NewCondition("deposit", "seq", EncodeSequence(1)).Address().String(),
+			wantCond:   true,
+		},
+		"malformed sequence condition": {
+			path:     "/address/convert/seq:escrow/seq/xyz",
+			wantCode: http.StatusBadRequest,
+		},
+		"malformed condition": {
+			path:     "/address/convert/cond:escrow/seq/xyz",
+			wantCode: http.StatusBadRequest,
+		},
+		"invalid contract ID": {
+			path:     "/address/convert/escrow:xyz",
+			wantCode: http.StatusBadRequest,
+		},
+		"garbage": {
+			path:     "/address/convert/!!!",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			h := AddressConvertHandler{}
+			r, _ := http.NewRequest("GET", tc.path, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d response, got %d: %s", tc.wantCode, w.Code, w.Body)
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			var conv AddressConversion
+			if err := json.NewDecoder(w.Body).Decode(&conv); err != nil {
+				t.Fatalf("cannot decode JSON response: %s", err)
+			}
+			if conv.Format != tc.wantFormat {
+				t.Errorf("want %q format, got %q", tc.wantFormat, conv.Format)
+			}
+			if conv.Address.Hex != tc.wantHex {
+				t.Errorf("want %q address, got %q", tc.wantHex, conv.Address.Hex)
+			}
+			if hasCond := conv.Condition != nil; hasCond != tc.wantCond {
+				t.Errorf("want condition %v, got %+v", tc.wantCond, conv.Condition)
+			}
+		})
+	}
+}
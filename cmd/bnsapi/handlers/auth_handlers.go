@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	weavecrypto "github.com/iov-one/weave/crypto"
+	"github.com/iov-one/weave/errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// authSignPrefix is prepended to every signed authentication message. It
+// separates the message from any other signed content, including weave
+// transaction sign bytes, which start with a binary version prefix.
+const authSignPrefix = "bnsapi-auth:"
+
+// authChallengeTTL is how long an issued challenge can be used.
+const authChallengeTTL = 5 * time.Minute
+
+// authMaxChallenges limits the number of outstanding challenges.
+const authMaxChallenges = 10000
+
+// AuthChallenges issues single use, expiring authentication challenges.
+// Challenges are kept in memory, so a challenge can be verified only by the
+// instance that issued it. The zero value is ready to use.
+type AuthChallenges struct {
+	mu      sync.Mutex
+	pending map[string]time.Time
+}
+
+// Issue returns a new challenge and the time it expires at.
+func (c *AuthChallenges) Issue(now time.Time) (string, time.Time, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.pending == nil {
+		c.pending = make(map[string]time.Time)
+	}
+	if len(c.pending) >= authMaxChallenges {
+		for challenge, expires := range c.pending {
+			if !now.Before(expires) {
+				delete(c.pending, challenge)
+			}
+		}
+		if len(c.pending) >= authMaxChallenges {
+			return "", time.Time{}, errors.Wrap(errors.ErrState, "too many pending challenges")
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", time.Time{}, errors.Wrap(err, "random")
+	}
+	challenge := hex.EncodeToString(raw)
+	expires := now.Add(authChallengeTTL)
+	c.pending[challenge] = expires
+	return challenge, expires, nil
+}
+
+// Consume removes the challenge and returns true if it was issued and did
+// not expire. A challenge can be consumed only once.
+func (c *AuthChallenges) Consume(challenge string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires, ok := c.pending[challenge]
+	if !ok {
+		return false
+	}
+	delete(c.pending, challenge)
+	return now.Before(expires)
+}
+
+// authSignMessage returns the message that must be signed to prove the
+// ownership of a starname.
+func authSignMessage(starname, challenge string) []byte {
+	return []byte(authSignPrefix + starname + ":" + challenge)
+}
+
+type AuthChallengeHandler struct {
+	Challenges *AuthChallenges
+}
+
+type authChallengeResponse struct {
+	Challenge string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// AuthChallengeHandler godoc
+// @Summary Issue a challenge that must be signed to verify the ownership of a starname.
+// @Description The challenge can be used once, until it expires. The message to sign is
+// @Description "bnsapi-auth:<starname>:<challenge>".
+// @Tags Starname
+// @Success 200 {object} handlers.authChallengeResponse
+// @Failure 503
+// @Router /auth/challenge [get]
+func (h *AuthChallengeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	challenge, expires, err := h.Challenges.Issue(time.Now())
+	if err != nil {
+		log.Printf("auth challenge: %s", err)
+		JSONErr(w, http.StatusServiceUnavailable, http.StatusText(http.StatusServiceUnavailable))
+		return
+	}
+	JSONResp(w, http.StatusOK, authChallengeResponse{
+		Challenge: challenge,
+		ExpiresAt: expires,
+	})
+}
+
+type AuthVerifyHandler struct {
+	Bns        client.BnsClient
+	Challenges *AuthChallenges
+}
+
+type authVerifyRequest struct {
+	Starname  string `json:"starname"`
+	Challenge string `json:"challenge"`
+	PubKey    string `json:"pubkey"`
+	Signature string `json:"signature"`
+}
+
+type authVerifyResponse struct {
+	Starname string        `json:"starname"`
+	Owner    weave.Address `json:"owner"`
+	Verified bool          `json:"verified"`
+}
+
+// AuthVerifyHandler godoc
+// @Summary Verify that a challenge was signed by the owner of a starname.
+// @Description Resolve the owner of a starname (account or username) and check that
+// @Description the given ed25519 public key belongs to that owner and that the signature
+// @Description of "bnsapi-auth:<starname>:<challenge>" is valid. The challenge must be issued
+// @Description by /auth/challenge and can be used once, before it expires.
+// @Description No transaction is submitted to the chain. Public key and signature must be hex encoded.
+// @Tags Starname
+// @Accept json
+// @Param body body handlers.authVerifyRequest true "starname, challenge, public key and signature"
+// @Success 200 {object} handlers.authVerifyResponse
+// @Failure 400
+// @Failure 401
+// @Failure 404
+// @Failure 500
+// @Router /auth/verify [post]
+func (h *AuthVerifyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		JSONErr(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	var req authVerifyRequest
+	if err := json.NewDecoder(io.LimitReader(r.Body, 1e5)).Decode(&req); err != nil {
+		JSONErr(w, http.StatusBadRequest, "request body must be a JSON object")
+		return
+	}
+	if req.Starname == "" || req.Challenge == "" {
+		JSONErr(w, http.StatusBadRequest, "starname and challenge must be provided")
+		return
+	}
+	rawKey, err := hex.DecodeString(req.PubKey)
+	if err != nil || len(rawKey) != 32 {
+		JSONErr(w, http.StatusBadRequest, "please provide a hex encoded ed25519 public key")
+		return
+	}
+	rawSig, err := hex.DecodeString(req.Signature)
+	if err != nil || len(rawSig) != 64 {
+		JSONErr(w, http.StatusBadRequest, "please provide a hex encoded ed25519 signature")
+		return
+	}
+
+	if !h.Challenges.Consume(req.Challenge, time.Now()) {
+		JSONErr(w, http.StatusUnauthorized, "unknown or expired challenge")
+		return
+	}
+
+	owner, err := h.starnameOwner(r, req.Starname)
+	switch {
+	case err == nil:
+	case errors.ErrNotFound.Is(err):
+		JSONErr(w, http.StatusNotFound, "Starname not found")
+		return
+	case errors.ErrExpired.Is(err):
+		JSONErr(w, http.StatusUnauthorized, "starname expired")
+		return
+	default:
+		log.Printf("auth owner ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	pubKey := weavecrypto.PublicKey_Ed25519{Ed25519: rawKey}
+	if !pubKey.Condition().Address().Equals(owner) {
+		JSONErr(w, http.StatusUnauthorized, "public key does not belong to the starname owner")
+		return
+	}
+	sig := weavecrypto.Signature{Sig: &weavecrypto.Signature_Ed25519{Ed25519: rawSig}}
+	if !pubKey.Verify(authSignMessage(req.Starname, req.Challenge), &sig) {
+		JSONErr(w, http.StatusUnauthorized, "invalid signature")
+		return
+	}
+
+	JSONResp(w, http.StatusOK, authVerifyResponse{
+		Starname: req.Starname,
+		Owner:    owner,
+		Verified: true,
+	})
+}
+
+// starnameOwner returns the address that controls given starname. Accounts
+// are checked first and an account without an owner is controlled by its
+// domain admin. An account is expired if either the account or its domain
+// is expired. If no account exists, username tokens are checked.
+func (h *AuthVerifyHandler) starnameOwner(r *http.Request, starname string) (weave.Address, error) {
+	var acc account.Account
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/accounts", []byte(starname), &models.KeyModel{Model: &acc}); {
+	case err == nil:
+		now := time.Now()
+		if acc.ValidUntil.Time().Before(now) {
+			return nil, errors.Wrap(errors.ErrExpired, "account")
+		}
+		var dom account.Domain
+		if err := client.ABCIKeyQuery(r.Context(), h.Bns, "/domains", []byte(acc.Domain), &models.KeyModel{Model: &dom}); err != nil {
+			return nil, errors.Wrap(err, "domain")
+		}
+		if dom.ValidUntil.Time().Before(now) {
+			return nil, errors.Wrap(errors.ErrExpired, "domain")
+		}
+		if len(acc.Owner) != 0 {
+			return acc.Owner, nil
+		}
+		return dom.Admin, nil
+	case errors.ErrNotFound.Is(err):
+	default:
+		return nil, errors.Wrap(err, "account")
+	}
+
+	var token username.Token
+	if err := client.ABCIKeyQuery(r.Context(), h.Bns, "/usernames", []byte(starname), &models.KeyModel{Model: &token}); err != nil {
+		return nil, errors.Wrap(err, "username")
+	}
+	return token.Owner, nil
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	weavecrypto "github.com/iov-one/weave/crypto"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthVerifyHandler(t *testing.T) {
+	hexKey := func(s string) string { return strings.ToUpper(hex.EncodeToString([]byte(s))) }
+	priv := weavecrypto.GenPrivKeyEd25519()
+	pub := priv.PublicKey()
+	other := weavecrypto.GenPrivKeyEd25519()
+	validUntil := weave.AsUnixTime(time.Now().Add(time.Hour))
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/accounts": {
+				hexKey("alice*neuma"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("alice*neuma")},
+					[]weave.Persistent{
+						&account.Account{Name: "alice", Domain: "neuma", Owner: pub.Address(), ValidUntil: validUntil},
+					}),
+				hexKey("alice*expired"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("alice*expired")},
+					[]weave.Persistent{
+						&account.Account{Name: "alice", Domain: "expired", Owner: pub.Address(), ValidUntil: validUntil},
+					}),
+			},
+			"/domains": {
+				hexKey("neuma"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("neuma")},
+					[]weave.Persistent{&account.Domain{Domain: "neuma", ValidUntil: validUntil}}),
+				hexKey("expired"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("expired")},
+					[]weave.Persistent{&account.Domain{Domain: "expired", ValidUntil: weave.AsUnixTime(time.Now().Add(-time.Hour))}}),
+			},
+		},
+	}
+	challenges := &AuthChallenges{}
+	h := AuthVerifyHandler{Bns: bns, Challenges: challenges}
+
+	issue := func() string {
+		challenge, _, err := challenges.Issue(time.Now())
+		if err != nil {
+			t.Fatalf("cannot issue challenge: %s", err)
+		}
+		return challenge
+	}
+	sign := func(p *weavecrypto.PrivateKey, msg []byte) string {
+		sig, err := p.Sign(msg)
+		if err != nil {
+			t.Fatalf("cannot sign: %s", err)
+		}
+		return hex.EncodeToString(sig.GetEd25519())
+	}
+	verify := func(starname, challenge, pubkey, signature string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"starname": %q, "challenge": %q, "pubkey": %q, "signature": %q}`, starname, challenge, pubkey, signature)
+		r, _ := http.NewRequest("POST", "/auth/verify", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+	pubHex := hex.EncodeToString(pub.GetEd25519())
+
+	cases := map[string]struct {
+		starname  string
+		pubkey    string
+		signature func(challenge string) string
+		wantCode  int
+	}{
+		"valid signature": {
+			pubkey:    pubHex,
+			signature: func(c string) string { return sign(priv, authSignMessage("alice*neuma", c)) },
+			wantCode:  http.StatusOK,
+		},
+		"signature of the challenge without the prefix": {
+			pubkey:    pubHex,
+			signature: func(c string) string { return sign(priv, []byte(c)) },
+			wantCode:  http.StatusUnauthorized,
+		},
+		"signature for a different starname": {
+			pubkey:    pubHex,
+			signature: func(c string) string { return sign(priv, authSignMessage("bob*neuma", c)) },
+			wantCode:  http.StatusUnauthorized,
+		},
+		"public key not owning the starname": {
+			pubkey:    hex.EncodeToString(other.PublicKey().GetEd25519()),
+			signature: func(c string) string { return sign(other, authSignMessage("alice*neuma", c)) },
+			wantCode:  http.StatusUnauthorized,
+		},
+		"expired domain": {
+			starname:  "alice*expired",
+			pubkey:    pubHex,
+			signature: func(c string) string { return sign(priv, authSignMessage("alice*expired", c)) },
+			wantCode:  http.StatusUnauthorized,
+		},
+		"malformed public key": {
+			pubkey:    "xyz",
+			signature: func(c string) string { return sign(priv, authSignMessage("alice*neuma", c)) },
+			wantCode:  http.StatusBadRequest,
+		},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			starname := tc.starname
+			if starname == "" {
+				starname = "alice*neuma"
+			}
+			challenge := issue()
+			w := verify(starname, challenge, tc.pubkey, tc.signature(challenge))
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d response, got %d: %s", tc.wantCode, w.Code, w.Body)
+			}
+		})
+	}
+
+	t.Run("challenge can be used once", func(t *testing.T) {
+		challenge := issue()
+		signature := sign(priv, authSignMessage("alice*neuma", challenge))
+		if w := verify("alice*neuma", challenge, pubHex, signature); w.Code != http.StatusOK {
+			t.Fatalf("want first verification to succeed, got %d: %s", w.Code, w.Body)
+		}
+		if w := verify("alice*neuma", challenge, pubHex, signature); w.Code != http.StatusUnauthorized {
+			t.Fatalf("want replay to be rejected, got %d: %s", w.Code, w.Body)
+		}
+	})
+
+	t.Run("unknown challenge", func(t *testing.T) {
+		signature := sign(priv, authSignMessage("alice*neuma", "challenge"))
+		if w := verify("alice*neuma", "challenge", pubHex, signature); w.Code != http.StatusUnauthorized {
+			t.Fatalf("want unknown challenge to be rejected, got %d: %s", w.Code, w.Body)
+		}
+	})
+}
+
+func TestAuthChallengesExpire(t *testing.T) {
+	var c AuthChallenges
+	now := time.Now()
+	challenge, expires, err := c.Issue(now)
+	if err != nil {
+		t.Fatalf("issue: %s", err)
+	}
+	if c.Consume(challenge, expires) {
+		t.Fatal("expired challenge must not be accepted")
+	}
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave/coin"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/x/cash"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type CashSupplyHandler struct {
+	Wallets *WalletIndex
+}
+
+// CashSupplyHandler godoc
+// @Summary Returns the total supply of each token, computed from all wallets.
+// @Description The result is cached, so it may not reflect the latest state.
+// @Tags IOV token
+// @Param height query int false "Block height at which the supply is computed"
+// @Success 200 {object} coin.Coins
+// @Failure 400
+// @Failure 500
+// @Router /cash/supply [get]
+func (h *CashSupplyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	height, err := heightParam(r, "height")
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	snap, err := h.Wallets.Snapshot(r.Context(), height)
+	if err != nil {
+		log.Printf("cash wallets index: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	JSONResp(w, http.StatusOK, snap.Supply)
+}
+
+type CashHoldersHandler struct {
+	Wallets *WalletIndex
+}
+
+// CashHoldersHandler godoc
+// @Summary Returns wallets holding the largest balance of a token, largest first.
+// @Description Wallets with an equal balance are ordered by their address.
+// @Description The result is cached, so it may not reflect the latest state.
+// @Tags IOV token
+// @Param ticker query string false "Token ticker, default IOV"
+// @Param top query int false "Number of holders to return, default 100, at most 1000"
+// @Param height query int false "Block height at which the balances are read"
+// @Success 200 {object} handlers.MultipleObjectsResponse
+// @Failure 400
+// @Failure 500
+// @Router /cash/holders [get]
+func (h *CashHoldersHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	ticker := q.Get("ticker")
+	if ticker == "" {
+		ticker = "IOV"
+	}
+	top := 100
+	if s := q.Get("top"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > util.PaginationMaxItems {
+			JSONErr(w, http.StatusBadRequest, fmt.Sprintf("top must be an integer between 1 and %d", util.PaginationMaxItems))
+			return
+		}
+		top = n
+	}
+	height, err := heightParam(r, "height")
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	snap, err := h.Wallets.Snapshot(r.Context(), height)
+	if err != nil {
+		log.Printf("cash wallets index: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	holders := snap.Holders[ticker]
+	if len(holders) > top {
+		holders = holders[:top]
+	}
+	objects := make([]util.KeyValue, 0, len(holders))
+	for _, h := range holders {
+		objects = append(objects, util.KeyValue{
+			Key:   h.key,
+			Value: h.wallet,
+		})
+	}
+	JSONResp(w, http.StatusOK, MultipleObjectsResponse{
+		Objects: objects,
+	})
+}
+
+// heightParam returns the block height requested with given query parameter
+// or zero if not provided.
+func heightParam(r *http.Request, name string) (int64, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 1 {
+		return 0, errors.Wrapf(errors.ErrInput, "%s must be a positive integer", name)
+	}
+	return n, nil
+}
+
+// WalletSnapshot is the state of all wallets at a given height.
+type WalletSnapshot struct {
+	// Supply is the sum of all wallet balances.
+	Supply coin.Coins
+	// Holders are the holders of each ticker, ordered by the balance,
+	// largest first, and by the wallet key.
+	Holders map[string][]holder
+}
+
+type holder struct {
+	key     []byte
+	wallet  *cash.Set
+	balance coin.Coin
+}
+
+// walletHistorySize is the number of snapshots at an explicit height kept in
+// memory.
+const walletHistorySize = 4
+
+// WalletIndex provides wallet snapshots. Building a snapshot requires reading
+// all wallets, so the latest one is rebuilt in the background at most once
+// every ttl. Snapshots at an explicit height never change, so only the few
+// most recently used are kept.
+type WalletIndex struct {
+	bns    client.BnsClient
+	latest *ttlIndex
+
+	mu sync.Mutex
+	// history is ordered from the least to the most recently used.
+	history []*heightSnapshot
+}
+
+// heightSnapshot is a snapshot at an explicit height. done is closed once
+// the build completes.
+type heightSnapshot struct {
+	height int64
+	done   chan struct{}
+	snap   *WalletSnapshot
+	err    error
+}
+
+// NewWalletIndex returns an index that rebuilds the latest snapshot at most
+// once every ttl.
+func NewWalletIndex(bns client.BnsClient, ttl time.Duration) *WalletIndex {
+	return &WalletIndex{
+		bns: bns,
+		latest: newTTLIndex(ttl, func(ctx context.Context) (interface{}, error) {
+			return buildWalletSnapshot(ctx, bns)
+		}),
+	}
+}
+
+// Snapshot returns the state of all wallets at given height. Zero height
+// means the latest state.
+func (ix *WalletIndex) Snapshot(ctx context.Context, height int64) (*WalletSnapshot, error) {
+	if height == 0 {
+		snap, err := ix.latest.get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return snap.(*WalletSnapshot), nil
+	}
+
+	hs := ix.heightSnapshot(height)
+	select {
+	case <-hs.done:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return hs.snap, hs.err
+}
+
+// heightSnapshot returns the snapshot at given height, starting its build if
+// it is not cached. Only one build per height runs at a time.
+func (ix *WalletIndex) heightSnapshot(height int64) *heightSnapshot {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	for i, hs := range ix.history {
+		if hs.height == height {
+			ix.history = append(append(ix.history[:i:i], ix.history[i+1:]...), hs)
+			return hs
+		}
+	}
+
+	hs := &heightSnapshot{height: height, done: make(chan struct{})}
+	ix.history = append(ix.history, hs)
+	if len(ix.history) > walletHistorySize {
+		ix.history = ix.history[len(ix.history)-walletHistorySize:]
+	}
+	go ix.build(hs)
+	return hs
+}
+
+func (ix *WalletIndex) build(hs *heightSnapshot) {
+	ctx, cancel := context.WithTimeout(context.Background(), indexBuildTimeout)
+	defer cancel()
+	hs.snap, hs.err = buildWalletSnapshot(client.WithHeight(ctx, hs.height), ix.bns)
+
+	if hs.err != nil {
+		// Do not cache a failure, so that the next request retries.
+		ix.mu.Lock()
+		for i, other := range ix.history {
+			if other == hs {
+				ix.history = append(ix.history[:i:i], ix.history[i+1:]...)
+				break
+			}
+		}
+		ix.mu.Unlock()
+	}
+	close(hs.done)
+}
+
+func buildWalletSnapshot(ctx context.Context, bns client.BnsClient) (*WalletSnapshot, error) {
+	snap := WalletSnapshot{
+		Supply:  coin.Coins{},
+		Holders: make(map[string][]holder),
+	}
+	it := client.ABCIFullRangeQuery(ctx, bns, "/wallets", "")
+	for {
+		var set cash.Set
+		switch key, err := it.Next(&set); {
+		case err == nil:
+			for _, c := range set.Coins {
+				if snap.Supply, err = snap.Supply.Add(*c); err != nil {
+					return nil, errors.Wrap(err, "supply")
+				}
+				snap.Holders[c.Ticker] = append(snap.Holders[c.Ticker], holder{
+					key:     key,
+					wallet:  &set,
+					balance: *c,
+				})
+			}
+		case errors.ErrIteratorDone.Is(err):
+			for _, holders := range snap.Holders {
+				holders := holders
+				sort.Slice(holders, func(i, j int) bool {
+					if c := holders[i].balance.Compare(holders[j].balance); c != 0 {
+						return c > 0
+					}
+					return bytes.Compare(holders[i].key, holders[j].key) < 0
+				})
+			}
+			return &snap, nil
+		default:
+			return nil, errors.Wrap(err, "wallets")
+		}
+	}
+}
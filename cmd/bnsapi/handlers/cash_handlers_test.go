@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/coin"
+	"github.com/iov-one/weave/x/cash"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCashHoldersHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+	walletKey := func(b byte) []byte { return append([]byte("cash:"), bytes.Repeat([]byte{b}, weave.AddressLength)...) }
+
+	small := &cash.Set{Coins: []*coin.Coin{coin.NewCoinp(1, 0, "IOV"), coin.NewCoinp(3, 0, "ETH")}}
+	first := &cash.Set{Coins: []*coin.Coin{coin.NewCoinp(7, 0, "IOV")}}
+	second := &cash.Set{Coins: []*coin.Coin{coin.NewCoinp(7, 0, "IOV")}}
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/wallets?range": {
+				// Wallets holding an equal balance are returned
+				// out of order to ensure they are sorted by key.
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{walletKey(1), walletKey(3), walletKey(2)},
+					[]weave.Persistent{small, second, first}),
+				// Range query is continued from the last returned key.
+				hexKey([]byte(fmt.Sprintf("%x:", walletKey(2)[5:]))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{walletKey(2)},
+					[]weave.Persistent{first}),
+			},
+			"/wallets?range@5": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{walletKey(1)},
+					[]weave.Persistent{small}),
+				hexKey([]byte(fmt.Sprintf("%x:", walletKey(1)[5:]))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{walletKey(1)},
+					[]weave.Persistent{small}),
+			},
+		},
+	}
+	wallets := NewWalletIndex(bns, time.Minute)
+
+	r, _ := http.NewRequest("GET", "/cash/holders?ticker=IOV&top=2", nil)
+	w := httptest.NewRecorder()
+	(&CashHoldersHandler{Wallets: wallets}).ServeHTTP(w, r)
+	bnsapitest.AssertAPIResponse(t, w, []util.KeyValue{
+		{Key: walletKey(2), Value: first},
+		{Key: walletKey(3), Value: second},
+	})
+
+	cases := map[string]coin.Coins{
+		"/cash/supply":          mustCombineCoins(t, coin.NewCoin(15, 0, "IOV"), coin.NewCoin(3, 0, "ETH")),
+		"/cash/supply?height=5": mustCombineCoins(t, coin.NewCoin(1, 0, "IOV"), coin.NewCoin(3, 0, "ETH")),
+	}
+	for path, want := range cases {
+		t.Run(path, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", path, nil)
+			w := httptest.NewRecorder()
+			(&CashSupplyHandler{Wallets: wallets}).ServeHTTP(w, r)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+			}
+			var supply coin.Coins
+			if err := json.NewDecoder(w.Body).Decode(&supply); err != nil {
+				t.Fatalf("cannot decode JSON response: %s", err)
+			}
+			if !supply.Equals(want) {
+				t.Fatalf("want %v supply, got %v", want, supply)
+			}
+		})
+	}
+}
+
+func mustCombineCoins(t testing.TB, cs ...coin.Coin) coin.Coins {
+	t.Helper()
+	coins, err := coin.CombineCoins(cs...)
+	if err != nil {
+		t.Fatalf("cannot combine coins: %s", err)
+	}
+	return coins
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/x/currency"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type CurrencyTokensHandler struct {
+	Bns client.BnsClient
+}
+
+// CurrencyTokensHandler godoc
+// @Summary Returns a list of x/currency TokenInfo entities.
+// @Description If ticker parameter is provided return the token information of that ticker only.
+// @Tags IOV token
+// @Param ticker query string false "Token ticker, ex: IOV"
+// @Success 200 {object} handlers.MultipleObjectsResponse
+// @Failure 404
+// @Failure 500
+// @Router /currency/tokens [get]
+func (h *CurrencyTokensHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if ticker := r.URL.Query().Get("ticker"); ticker != "" {
+		var info currency.TokenInfo
+		res := models.KeyModel{
+			Model: &info,
+		}
+		switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/tokens", []byte(ticker), &res); {
+		case err == nil:
+			JSONResp(w, http.StatusOK, res)
+		case errors.ErrNotFound.Is(err):
+			JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		default:
+			log.Printf("currency token ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return
+	}
+
+	it := client.ABCIPrefixQuery(r.Context(), h.Bns, "/tokens", []byte{})
+	objects := make([]util.KeyValue, 0, util.PaginationMaxItems)
+fetchTokens:
+	for {
+		var info currency.TokenInfo
+		switch key, err := it.Next(&info); {
+		case err == nil:
+			objects = append(objects, util.KeyValue{
+				Key:   key,
+				Value: &info,
+			})
+			if len(objects) == util.PaginationMaxItems {
+				break fetchTokens
+			}
+		case errors.ErrIteratorDone.Is(err):
+			break fetchTokens
+		default:
+			log.Printf("currency tokens ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	JSONResp(w, http.StatusOK, MultipleObjectsResponse{
+		Objects: objects,
+	})
+}
+
+// AmountFormat is a middleware that renders all coin.Coin values of a JSON
+// response as decimal amounts together with the token name and precision,
+// if requested with the amounts=decimal query parameter.
+func AmountFormat(bns client.BnsClient, next http.Handler) http.Handler {
+	tokens := tokenNames{bns: bns, ttl: time.Minute}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch format := r.URL.Query().Get("amounts"); format {
+		case "":
+			next.ServeHTTP(w, r)
+		case "decimal":
+			names, err := tokens.Names(r.Context())
+			if err != nil {
+				log.Printf("currency tokens ABCI query: %s", err)
+				JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				return
+			}
+			rd, r := withRenderer(r)
+			rd.decimal = true
+			rd.tokens = names
+			next.ServeHTTP(newRenderWriter(w, rd), r)
+		default:
+			JSONErr(w, http.StatusBadRequest, fmt.Sprintf("unknown amounts format %q", format))
+		}
+	})
+}
+
+// tokenNames provides a cached mapping of ticker to token name. Tokens are
+// rarely registered so the mapping is refreshed only after the ttl passed.
+type tokenNames struct {
+	bns client.BnsClient
+	ttl time.Duration
+
+	mu      sync.Mutex
+	names   map[string]string
+	fetched time.Time
+}
+
+func (tn *tokenNames) Names(ctx context.Context) (map[string]string, error) {
+	tn.mu.Lock()
+	defer tn.mu.Unlock()
+
+	if tn.names != nil && time.Since(tn.fetched) < tn.ttl {
+		return tn.names, nil
+	}
+
+	names := make(map[string]string)
+	it := client.ABCIPrefixQuery(ctx, tn.bns, "/tokens", []byte{})
+	for {
+		var info currency.TokenInfo
+		switch key, err := it.Next(&info); {
+		case err == nil:
+			// Skip the bucket prefix, being the characters before : (including separator)
+			ticker := key[bytes.Index(key, []byte(":"))+1:]
+			names[string(ticker)] = info.Name
+		case errors.ErrIteratorDone.Is(err):
+			tn.names = names
+			tn.fetched = time.Now()
+			return names, nil
+		default:
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/coin"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/currency"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCurrencyTokensHandler(t *testing.T) {
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/tokens?prefix": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{
+						[]byte("tokeninfo:IOV"),
+					},
+					[]weave.Persistent{
+						&currency.TokenInfo{Name: "Main token"},
+					}),
+			},
+		},
+	}
+	h := CurrencyTokensHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/currency/tokens", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	bnsapitest.AssertAPIResponse(t, w, []util.KeyValue{
+		{
+			Key:   []byte("tokeninfo:IOV"),
+			Value: &currency.TokenInfo{Name: "Main token"},
+		},
+	})
+}
+
+func TestAmountFormat(t *testing.T) {
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/tokens?prefix": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{
+						[]byte("tokeninfo:IOV"),
+					},
+					[]weave.Persistent{
+						&currency.TokenInfo{Name: "Main token"},
+					}),
+			},
+		},
+	}
+	h := AmountFormat(bns, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		JSONResp(w, http.StatusOK, cash.Set{
+			Coins: []*coin.Coin{coin.NewCoinp(12, 500000000, "IOV")},
+		})
+	}))
+
+	r, _ := http.NewRequest("GET", "/?amounts=decimal", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var set struct {
+		Coins []decimalCoin
+	}
+	if err := json.NewDecoder(w.Body).Decode(&set); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	want := decimalCoin{
+		Amount:    "12.5",
+		Ticker:    "IOV",
+		Display:   "12.5 IOV",
+		Name:      "Main token",
+		Precision: 9,
+	}
+	if len(set.Coins) != 1 || set.Coins[0] != want {
+		t.Fatalf("unexpected coins: %+v", set.Coins)
+	}
+}
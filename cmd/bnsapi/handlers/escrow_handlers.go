@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/escrow"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Escrow statuses as computed by the EscrowDetailHandler.
+const (
+	// EscrowActive is an escrow that holds funds and can be released by
+	// the arbiter.
+	EscrowActive = "active"
+	// EscrowExpired is an escrow that holds funds and passed its timeout.
+	// Its funds can be returned to the source.
+	EscrowExpired = "expired"
+	// EscrowEmpty is an escrow that does not hold any funds.
+	EscrowEmpty = "empty"
+)
+
+type EscrowDetailHandler struct {
+	Bns client.BnsClient
+}
+
+type escrowDetail struct {
+	Escrow    *escrow.Escrow  `json:"escrow"`
+	Condition weave.Condition `json:"condition"`
+	Address   weave.Address   `json:"address"`
+	Balance   *cash.Set       `json:"balance"`
+	Status    string          `json:"status"`
+}
+
+// EscrowDetailHandler godoc
+// @Summary Returns an escrow together with its address, balance and status.
+// @Description Status is computed from the escrow balance and timeout. It is one of
+// @Description active (can be released), expired (passed timeout and can be returned to the source)
+// @Description or empty (does not hold any funds).
+// @Tags IOV token
+// @Param escrowID path int true "Escrow ID"
+// @Success 200 {object} handlers.escrowDetail
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /escrow/escrows/{escrowID} [get]
+func (h *EscrowDetailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := ExtractNumericID(LastChunk(r.URL.Path))
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, "escrow ID must be an integer")
+		return
+	}
+
+	var e escrow.Escrow
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/escrows", id, &models.KeyModel{Model: &e}); {
+	case err == nil:
+	case errors.ErrNotFound.Is(err):
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	default:
+		log.Printf("escrow ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	cond := escrow.Condition(id)
+	balance, err := walletBalance(r.Context(), h.Bns, cond.Address())
+	if err != nil {
+		log.Printf("escrow wallet ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	status := EscrowActive
+	switch {
+	case len(balance.Coins) == 0:
+		status = EscrowEmpty
+	case !e.Timeout.Time().After(time.Now()):
+		status = EscrowExpired
+	}
+
+	JSONResp(w, http.StatusOK, escrowDetail{
+		Escrow:    &e,
+		Condition: cond,
+		Address:   cond.Address(),
+		Balance:   balance,
+		Status:    status,
+	})
+}
+
+// walletBalance returns the funds that given address holds. An address
+// without a wallet holds no funds.
+func walletBalance(ctx context.Context, bns client.BnsClient, addr weave.Address) (*cash.Set, error) {
+	var set cash.Set
+	switch err := client.ABCIKeyQuery(ctx, bns, "/wallets", addr, &models.KeyModel{Model: &set}); {
+	case err == nil:
+		return &set, nil
+	case errors.ErrNotFound.Is(err):
+		return &cash.Set{}, nil
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/coin"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/escrow"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEscrowDetailHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+
+	funded := escrow.Condition(EncodeSequence(1)).Address()
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/escrows": {
+				hexKey(EncodeSequence(1)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(1)},
+					[]weave.Persistent{&escrow.Escrow{Timeout: weave.AsUnixTime(time.Now().Add(time.Hour))}}),
+				hexKey(EncodeSequence(2)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(2)},
+					[]weave.Persistent{&escrow.Escrow{Timeout: weave.AsUnixTime(time.Now().Add(-time.Hour))}}),
+				hexKey(EncodeSequence(3)): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+			"/wallets": {
+				hexKey(funded): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{funded},
+					[]weave.Persistent{&cash.Set{Coins: []*coin.Coin{coin.NewCoinp(5, 0, "IOV")}}}),
+				hexKey(escrow.Condition(EncodeSequence(2)).Address()): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+		},
+	}
+	h := EscrowDetailHandler{Bns: bns}
+
+	cases := map[string]struct {
+		path       string
+		wantCode   int
+		wantStatus string
+	}{
+		"funded escrow":  {path: "/escrow/escrows/1", wantCode: http.StatusOK, wantStatus: EscrowActive},
+		"empty escrow":   {path: "/escrow/escrows/2", wantCode: http.StatusOK, wantStatus: EscrowEmpty},
+		"missing escrow": {path: "/escrow/escrows/3", wantCode: http.StatusNotFound},
+		"invalid ID":     {path: "/escrow/escrows/abc", wantCode: http.StatusBadRequest},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tc.path, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d response, got %d: %s", tc.wantCode, w.Code, w.Body)
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			var detail struct {
+				Status string
+			}
+			if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+				t.Fatalf("cannot decode JSON response: %s", err)
+			}
+			if detail.Status != tc.wantStatus {
+				t.Fatalf("want %q status, got %q", tc.wantStatus, detail.Status)
+			}
+		})
+	}
+}
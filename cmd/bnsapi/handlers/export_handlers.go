@@ -0,0 +1,272 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/termdeposit"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/escrow"
+	"github.com/iov-one/weave/x/gov"
+	"github.com/iov-one/weave/x/multisig"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// exportCollections maps collection names to the bucket they are read from.
+var exportCollections = map[string]struct {
+	path     string
+	newModel func() orm.Model
+}{
+	"accounts":         {"/accounts", func() orm.Model { return &account.Account{} }},
+	"domains":          {"/domains", func() orm.Model { return &account.Domain{} }},
+	"usernames":        {"/usernames", func() orm.Model { return &username.Token{} }},
+	"wallets":          {"/wallets", func() orm.Model { return &cash.Set{} }},
+	"escrows":          {"/escrows", func() orm.Model { return &escrow.Escrow{} }},
+	"multisig":         {"/contracts", func() orm.Model { return &multisig.Contract{} }},
+	"deposits":         {"/deposits", func() orm.Model { return &termdeposit.Deposit{} }},
+	"depositcontracts": {"/depositcontracts", func() orm.Model { return &termdeposit.DepositContract{} }},
+	"proposals":        {"/proposals", func() orm.Model { return &gov.Proposal{} }},
+	"votes":            {"/votes", func() orm.Model { return &gov.Vote{} }},
+	"electorates":      {"/electorates", func() orm.Model { return &gov.Electorate{} }},
+	"electionrules":    {"/electionrules", func() orm.Model { return &gov.ElectionRule{} }},
+}
+
+// exportFlushEvery is the number of exported entities after which the
+// response is flushed to the client.
+const exportFlushEvery = 100
+
+type ExportHandler struct {
+	Bns client.BnsClient
+}
+
+// ExportHandler godoc
+// @Summary Streams all entities of a collection.
+// @Description Unlike the listing endpoints, the whole collection is returned without pagination.
+// @Description The response is streamed either as newline delimited JSON, one {key, value} object per line,
+// @Description or as CSV with the key and one column per top level field. Nested values are serialized as JSON.
+// @Description If the export fails after the response has started, the connection is aborted.
+// @Description Available collections are accounts, domains, usernames, wallets, escrows, multisig, deposits,
+// @Description depositcontracts, proposals, votes, electorates and electionrules.
+// @Tags Export
+// @Param collection path string true "Collection name, ex: accounts"
+// @Param format query string false "Export format: ndjson (default) or csv"
+// @Success 200
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /export/{collection} [get]
+func (h *ExportHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := LastChunk(r.URL.Path)
+	collection, ok := exportCollections[name]
+	if !ok {
+		known := make([]string, 0, len(exportCollections))
+		for n := range exportCollections {
+			known = append(known, n)
+		}
+		sort.Strings(known)
+		JSONErr(w, http.StatusNotFound, fmt.Sprintf("Unknown collection. Supported collections are %q", known))
+		return
+	}
+
+	var enc exportEncoder
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson; charset=UTF-8")
+		enc = &ndjsonEncoder{w: w}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+		enc = newCSVEncoder(w, collection.newModel())
+	default:
+		JSONErr(w, http.StatusBadRequest, fmt.Sprintf("unknown format %q", format))
+		return
+	}
+
+	it := client.ABCIFullRangeQuery(r.Context(), h.Bns, collection.path, "")
+	flusher, _ := w.(http.Flusher)
+	for n := 0; ; n++ {
+		m := collection.newModel()
+		key, err := it.Next(m)
+		switch {
+		case err == nil:
+		case errors.ErrIteratorDone.Is(err):
+			if n == 0 {
+				w.WriteHeader(http.StatusOK)
+			}
+			if err := enc.Flush(); err != nil {
+				log.Printf("export %s: %s", name, err)
+			}
+			return
+		case n == 0:
+			// Nothing was written yet, so an error response can be
+			// returned.
+			log.Printf("export %s ABCI query: %s", name, err)
+			w.Header().Del("Content-Disposition")
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		default:
+			log.Printf("export %s ABCI query: %s", name, err)
+			panic(http.ErrAbortHandler)
+		}
+
+		if err := enc.Encode(util.KeyValue{Key: key, Value: m}); err != nil {
+			log.Printf("export %s: %s", name, err)
+			panic(http.ErrAbortHandler)
+		}
+		if flusher != nil && n%exportFlushEvery == exportFlushEvery-1 {
+			if err := enc.Flush(); err != nil {
+				log.Printf("export %s: %s", name, err)
+				panic(http.ErrAbortHandler)
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// exportEncoder writes exported entities. Flush writes any buffered data
+// to the underlying writer.
+type exportEncoder interface {
+	Encode(util.KeyValue) error
+	Flush() error
+}
+
+type ndjsonEncoder struct {
+	w http.ResponseWriter
+}
+
+func (e *ndjsonEncoder) Encode(kv util.KeyValue) error {
+	b, err := json.Marshal(renderContent(e.w, kv))
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *ndjsonEncoder) Flush() error {
+	return nil
+}
+
+type csvEncoder struct {
+	w       http.ResponseWriter
+	cw      *csv.Writer
+	columns []string
+	header  bool
+}
+
+// newCSVEncoder returns an encoder with a column for each top level JSON
+// field of given model.
+func newCSVEncoder(w http.ResponseWriter, model orm.Model) *csvEncoder {
+	return &csvEncoder{
+		w:       w,
+		cw:      csv.NewWriter(w),
+		columns: jsonFieldNames(reflect.TypeOf(model)),
+	}
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.header {
+		return nil
+	}
+	e.header = true
+	return e.cw.Write(append([]string{"key"}, e.columns...))
+}
+
+func (e *csvEncoder) Encode(kv util.KeyValue) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(renderContent(e.w, kv.Value))
+	if err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	key, err := json.Marshal(kv.Key)
+	if err != nil {
+		return err
+	}
+	row := make([]string, 0, len(e.columns)+1)
+	row = append(row, csvCell(key))
+	for _, c := range e.columns {
+		row = append(row, csvCell(fields[c]))
+	}
+	return e.cw.Write(row)
+}
+
+func (e *csvEncoder) Flush() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.cw.Flush()
+	return e.cw.Error()
+}
+
+// csvCell returns the JSON value as a CSV cell. Strings are unquoted and
+// null is an empty cell.
+func csvCell(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// jsonFieldNames returns the names of all top level JSON fields of given
+// structure type, in the declaration order.
+func jsonFieldNames(t reflect.Type) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := tag
+		if i := strings.Index(tag, ","); i >= 0 {
+			name = tag[:i]
+		}
+		if sf.Anonymous && name == "" && sf.Type.Kind() == reflect.Struct {
+			names = append(names, jsonFieldNames(sf.Type)...)
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// renderContent returns the content as JSONResp would serialize it for given
+// writer.
+func renderContent(w io.Writer, content interface{}) interface{} {
+	if rw, ok := w.(*renderWriter); ok {
+		return rw.render(reflect.ValueOf(content))
+	}
+	return content
+}
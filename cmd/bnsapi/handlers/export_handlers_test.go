@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExportHandler(t *testing.T) {
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/accounts?range": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("account:alice*neuma"), []byte("account:bob*neuma")},
+					[]weave.Persistent{
+						&account.Account{Name: "alice", Domain: "neuma"},
+						&account.Account{Name: "bob", Domain: "neuma"},
+					}),
+				// Range query is continued from the last returned key.
+				strings.ToUpper(hex.EncodeToString([]byte(fmt.Sprintf("%x:", "bob*neuma")))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("account:bob*neuma")},
+					[]weave.Persistent{&account.Account{Name: "bob", Domain: "neuma"}}),
+			},
+		},
+	}
+	h := ExportHandler{Bns: bns}
+
+	cases := map[string]struct {
+		path     string
+		wantCode int
+		wantBody string
+	}{
+		"ndjson": {
+			path:     "/export/accounts",
+			wantCode: http.StatusOK,
+			wantBody: `{"key":"6163636f756e743a616c6963652a6e65756d61","value":{"domain":"neuma","name":"alice","targets":null}}
+{"key":"6163636f756e743a626f622a6e65756d61","value":{"domain":"neuma","name":"bob","targets":null}}
+`,
+		},
+		"csv": {
+			path:     "/export/accounts?format=csv",
+			wantCode: http.StatusOK,
+			wantBody: `key,metadata,domain,name,owner,valid_until,targets,certificates,broker
+6163636f756e743a616c6963652a6e65756d61,,neuma,alice,,,,,
+6163636f756e743a626f622a6e65756d61,,neuma,bob,,,,,
+`,
+		},
+		"unknown collection": {
+			path:     "/export/unknown",
+			wantCode: http.StatusNotFound,
+		},
+		"unknown format": {
+			path:     "/export/accounts?format=xml",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tc.path, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d status code, got %d", tc.wantCode, w.Code)
+			}
+			if tc.wantBody != "" && w.Body.String() != tc.wantBody {
+				t.Fatalf("unexpected body:\n%s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/gconf"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type GconfSnapshotHandler struct {
+	Bns   client.BnsClient
+	Confs *GconfRegistry
+}
+
+// GconfSnapshotHandler godoc
+// @Summary Returns all registered configurations, mapped by the extension name.
+// @Description Extensions that have no configuration stored are not included.
+// @Tags Status
+// @Param height query int false "Block height to read the configurations at, defaults to the latest"
+// @Success 200
+// @Failure 400
+// @Failure 500
+// @Router /gconf [get]
+func (h *GconfSnapshotHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	height, err := heightParam(r, "height")
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	ctx := client.WithHeight(r.Context(), height)
+
+	names := h.Confs.Names()
+	confs := make(map[string]gconf.Configuration, len(names))
+	for _, name := range names {
+		conf, _ := h.Confs.New(name)
+		switch err := client.ABCIKeyQuery(ctx, h.Bns, "/gconf", []byte(name), &models.KeyModel{Model: conf}); {
+		case err == nil:
+			confs[name] = conf
+		case errors.ErrNotFound.Is(err):
+			// Extension not in use on this chain.
+		default:
+			log.Printf("gconf ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	JSONResp(w, http.StatusOK, confs)
+}
+
+type gconfHistory struct {
+	Extension string `json:"extension"`
+	From      int64  `json:"from"`
+	To        int64  `json:"to"`
+	// Initial is the configuration at the From height, or null if the
+	// configuration was not set yet.
+	Initial gconf.Configuration `json:"initial"`
+	Changes []gconfChange       `json:"changes"`
+}
+
+type gconfChange struct {
+	// Height is the first block height with the new configuration.
+	Height int64 `json:"height"`
+	// Configuration is the configuration after the change, or null if it
+	// was removed.
+	Configuration gconf.Configuration `json:"configuration"`
+	Diff          []gconfFieldDiff    `json:"diff"`
+}
+
+type gconfFieldDiff struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old"`
+	New   json.RawMessage `json:"new"`
+}
+
+// serveHistory godoc
+// @Summary Returns the heights at which an extension configuration changed, together with the changed fields.
+// @Description Changes are discovered by bisecting the height range with height-pinned queries,
+// @Description so a change that was reverted before the next checked height may not be listed.
+// @Description The node must not have pruned the state of the requested range.
+// @Tags Status
+// @Param extensionName path string true "Extension name"
+// @Param from query int false "First block height to search from, defaults to 1"
+// @Param to query int false "Last block height to search to, defaults to the latest"
+// @Success 200 {object} handlers.gconfHistory
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /gconf/{extensionName}/history [get]
+func (h *GconfHandler) serveHistory(w http.ResponseWriter, r *http.Request) {
+	extensionName := LastChunk(strings.TrimSuffix(r.URL.Path, "/history"))
+
+	from, err := heightParam(r, "from")
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if from == 0 {
+		from = 1
+	}
+	to, err := heightParam(r, "to")
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	latest, latestHeight, err := client.ABCIRawKeyQuery(ctx, h.Bns, "/gconf", []byte(extensionName))
+	if err != nil {
+		log.Printf("gconf ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	if latest == nil {
+		// An extension that is not configured at the latest height
+		// was either never configured or is no longer in use.
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+	if to == 0 || to > latestHeight {
+		to = latestHeight
+	}
+	if from > to {
+		JSONErr(w, http.StatusBadRequest, "from must not be greater than to")
+		return
+	}
+
+	var last []byte
+	if to == latestHeight {
+		last = latest
+	} else if last, err = gconfAt(ctx, h.Bns, extensionName, to); err != nil {
+		log.Printf("gconf ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	first, err := gconfAt(ctx, h.Bns, extensionName, from)
+	if err != nil {
+		log.Printf("gconf ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	versions, err := gconfChanges(ctx, h.Bns, extensionName, from, first, to, last)
+	if err != nil {
+		log.Printf("gconf ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	decode := func(raw []byte) (gconf.Configuration, error) {
+		if raw == nil {
+			return nil, nil
+		}
+		conf, _ := h.Confs.New(extensionName)
+		if err := conf.Unmarshal(raw); err != nil {
+			return nil, errors.Wrap(err, "unmarshal configuration")
+		}
+		return conf, nil
+	}
+
+	initial, err := decode(first)
+	if err != nil {
+		log.Printf("gconf history: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	res := gconfHistory{
+		Extension: extensionName,
+		From:      from,
+		To:        to,
+		Initial:   initial,
+		Changes:   make([]gconfChange, 0, len(versions)),
+	}
+	prev := initial
+	for _, v := range versions {
+		conf, err := decode(v.raw)
+		if err != nil {
+			log.Printf("gconf history: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		diff, err := gconfDiff(prev, conf)
+		if err != nil {
+			log.Printf("gconf history: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		res.Changes = append(res.Changes, gconfChange{
+			Height:        v.height,
+			Configuration: conf,
+			Diff:          diff,
+		})
+		prev = conf
+	}
+
+	JSONResp(w, http.StatusOK, res)
+}
+
+type gconfVersion struct {
+	height int64
+	raw    []byte
+}
+
+// gconfAt returns the serialized configuration of given extension at given
+// height or nil if it was not set.
+func gconfAt(ctx context.Context, bns client.BnsClient, extensionName string, height int64) ([]byte, error) {
+	raw, _, err := client.ABCIRawKeyQuery(client.WithHeight(ctx, height), bns, "/gconf", []byte(extensionName))
+	return raw, err
+}
+
+// gconfChanges returns, in ascending order, all heights within (from, to]
+// at which the serialized configuration differs from the one at the previous
+// height. The range is bisected until each change is pinned to a single
+// height, so only a range with different configurations at both ends is
+// searched.
+func gconfChanges(ctx context.Context, bns client.BnsClient, extensionName string, from int64, first []byte, to int64, last []byte) ([]gconfVersion, error) {
+	var versions []gconfVersion
+	var bisect func(lo int64, vlo []byte, hi int64, vhi []byte) error
+	bisect = func(lo int64, vlo []byte, hi int64, vhi []byte) error {
+		if bytes.Equal(vlo, vhi) {
+			return nil
+		}
+		if hi-lo == 1 {
+			versions = append(versions, gconfVersion{height: hi, raw: vhi})
+			return nil
+		}
+		mid := lo + (hi-lo)/2
+		vmid, err := gconfAt(ctx, bns, extensionName, mid)
+		if err != nil {
+			return errors.Wrapf(err, "height %d", mid)
+		}
+		if err := bisect(lo, vlo, mid, vmid); err != nil {
+			return err
+		}
+		return bisect(mid, vmid, hi, vhi)
+	}
+	if err := bisect(from, first, to, last); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// gconfDiff returns all top level fields of the JSON representation that
+// differ between two configurations. Either configuration can be nil.
+func gconfDiff(prev, next gconf.Configuration) ([]gconfFieldDiff, error) {
+	oldFields, err := jsonFields(prev)
+	if err != nil {
+		return nil, errors.Wrap(err, "old configuration")
+	}
+	newFields, err := jsonFields(next)
+	if err != nil {
+		return nil, errors.Wrap(err, "new configuration")
+	}
+
+	names := make([]string, 0, len(oldFields)+len(newFields))
+	for name := range oldFields {
+		names = append(names, name)
+	}
+	for name := range newFields {
+		if _, ok := oldFields[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	diff := make([]gconfFieldDiff, 0, len(names))
+	for _, name := range names {
+		o, n := oldFields[name], newFields[name]
+		if bytes.Equal(o, n) {
+			continue
+		}
+		diff = append(diff, gconfFieldDiff{Field: name, Old: o, New: n})
+	}
+	return diff, nil
+}
+
+func jsonFields(conf gconf.Configuration) (map[string]json.RawMessage, error) {
+	if conf == nil {
+		return nil, nil
+	}
+	raw, err := json.Marshal(conf)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
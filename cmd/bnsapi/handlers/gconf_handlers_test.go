@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/coin"
+	"github.com/iov-one/weave/gconf"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/msgfee"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGconfSnapshotHandler(t *testing.T) {
+	hexKey := func(s string) string { return strings.ToUpper(hex.EncodeToString([]byte(s))) }
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/gconf": {
+				hexKey("cash"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("cash")},
+					[]weave.Persistent{&cash.Configuration{MinimalFee: coin.NewCoin(0, 100, "IOV")}}),
+				hexKey("msgfee"): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+		},
+	}
+	var reg GconfRegistry
+	reg.Register("cash", func() gconf.Configuration { return &cash.Configuration{} })
+	reg.Register("msgfee", func() gconf.Configuration { return &msgfee.Configuration{} })
+	h := GconfSnapshotHandler{Bns: bns, Confs: &reg}
+
+	r, _ := http.NewRequest("GET", "/gconf", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var confs map[string]cash.Configuration
+	if err := json.NewDecoder(w.Body).Decode(&confs); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if len(confs) != 1 {
+		t.Fatalf("want only cash configuration, got %v", confs)
+	}
+	if fee := confs["cash"].MinimalFee; !fee.Equals(coin.NewCoin(0, 100, "IOV")) {
+		t.Fatalf("unexpected minimal fee: %v", fee)
+	}
+}
+
+func TestGconfHistory(t *testing.T) {
+	key := strings.ToUpper(hex.EncodeToString([]byte("cash")))
+	before := &cash.Configuration{MinimalFee: coin.NewCoin(0, 100, "IOV")}
+	after := &cash.Configuration{MinimalFee: coin.NewCoin(0, 500, "IOV")}
+	confAt := func(conf *cash.Configuration) map[string]models.AbciQueryResponse {
+		return map[string]models.AbciQueryResponse{
+			key: bnsapitest.NewAbciQueryResponse(t, [][]byte{[]byte("cash")}, []weave.Persistent{conf}),
+		}
+	}
+
+	latest := confAt(after)
+	resp := latest[key]
+	resp.Response.Height = 8
+	latest[key] = resp
+
+	// The fee changed at height 5. Bisecting the 1-8 range queries
+	// heights 4, 6 and 5.
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/gconf":   latest,
+			"/gconf@1": confAt(before),
+			"/gconf@4": confAt(before),
+			"/gconf@5": confAt(after),
+			"/gconf@6": confAt(after),
+		},
+	}
+	h := GconfHandler{Bns: bns, Confs: NewGconfRegistry()}
+
+	r, _ := http.NewRequest("GET", "/gconf/cash/history", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var history struct {
+		From    int64
+		To      int64
+		Changes []struct {
+			Height int64
+			Diff   []struct {
+				Field string
+				Old   coin.Coin
+				New   coin.Coin
+			}
+		}
+	}
+	if err := json.NewDecoder(w.Body).Decode(&history); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if history.From != 1 || history.To != 8 {
+		t.Fatalf("unexpected range: %d-%d", history.From, history.To)
+	}
+	if len(history.Changes) != 1 || history.Changes[0].Height != 5 {
+		t.Fatalf("unexpected changes: %+v", history.Changes)
+	}
+	diff := history.Changes[0].Diff
+	if len(diff) != 1 || diff[0].Field != "minimal_fee" || diff[0].Old.Fractional != 100 || diff[0].New.Fractional != 500 {
+		t.Fatalf("unexpected diff: %+v", diff)
+	}
+}
+
+func TestGconfHistoryNotFound(t *testing.T) {
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/gconf": {
+				strings.ToUpper(hex.EncodeToString([]byte("cash"))): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+		},
+	}
+	h := GconfHandler{Bns: bns, Confs: NewGconfRegistry()}
+
+	r, _ := http.NewRequest("GET", "/gconf/cash/history", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestGconfHandlerUnknownExtension(t *testing.T) {
+	// Configuration of an extension unknown to the registry.
+	conf := &cash.Configuration{
+		Metadata:   &weave.Metadata{Schema: 1},
+		MinimalFee: coin.NewCoin(0, 100, "IOV"),
+	}
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/gconf": {
+				strings.ToUpper(hex.EncodeToString([]byte("custom"))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("custom")},
+					[]weave.Persistent{conf}),
+			},
+		},
+	}
+	h := GconfHandler{Bns: bns, Confs: NewGconfRegistry()}
+
+	r, _ := http.NewRequest("GET", "/gconf/custom", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var res struct {
+		Model map[string]interface{}
+	}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	// Field 4 is the minimal fee coin, with fractional (2) and ticker (3).
+	fee, ok := res.Model["4"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("unexpected configuration: %v", res.Model)
+	}
+	if fee["2"] != float64(100) || fee["3"] != "IOV" {
+		t.Fatalf("unexpected fee: %v", fee)
+	}
+}
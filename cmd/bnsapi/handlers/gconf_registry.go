@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/preregistration"
+	"github.com/iov-one/weave/cmd/bnsd/x/qualityscore"
+	"github.com/iov-one/weave/cmd/bnsd/x/termdeposit"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/gconf"
+	"github.com/iov-one/weave/migration"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/msgfee"
+	"github.com/iov-one/weave/x/txfee"
+	"sort"
+	"strconv"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GconfRegistry maps extension names to the gconf.Configuration
+// implementation that their configuration is stored as. Programs embedding
+// the handlers can register additional extensions, for example custom weave
+// extensions of a fork or a test chain. Zero value is an empty registry.
+type GconfRegistry struct {
+	mu    sync.RWMutex
+	confs map[string]func() gconf.Configuration
+}
+
+// NewGconfRegistry returns a registry with the configurations of all bnsd
+// extensions registered.
+func NewGconfRegistry() *GconfRegistry {
+	reg := &GconfRegistry{}
+	reg.Register("account", func() gconf.Configuration { return &account.Configuration{} })
+	reg.Register("cash", func() gconf.Configuration { return &cash.Configuration{} })
+	reg.Register("migration", func() gconf.Configuration { return &migration.Configuration{} })
+	reg.Register("msgfee", func() gconf.Configuration { return &msgfee.Configuration{} })
+	reg.Register("preregistration", func() gconf.Configuration { return &preregistration.Configuration{} })
+	reg.Register("qualityscore", func() gconf.Configuration { return &qualityscore.Configuration{} })
+	reg.Register("termdeposit", func() gconf.Configuration { return &termdeposit.Configuration{} })
+	reg.Register("txfee", func() gconf.Configuration { return &txfee.Configuration{} })
+	reg.Register("username", func() gconf.Configuration { return &username.Configuration{} })
+	return reg
+}
+
+// Register sets the configuration factory for given extension name,
+// replacing any previously registered one.
+func (reg *GconfRegistry) Register(extensionName string, fn func() gconf.Configuration) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.confs == nil {
+		reg.confs = make(map[string]func() gconf.Configuration)
+	}
+	reg.confs[extensionName] = fn
+}
+
+// New returns a new, empty configuration instance of given extension. If the
+// extension is not registered, a RawConfiguration is returned instead and
+// ok is false.
+func (reg *GconfRegistry) New(extensionName string) (conf gconf.Configuration, ok bool) {
+	reg.mu.RLock()
+	fn, ok := reg.confs[extensionName]
+	reg.mu.RUnlock()
+	if !ok {
+		return &RawConfiguration{}, false
+	}
+	return fn(), true
+}
+
+// Names returns the sorted names of all registered extensions.
+func (reg *GconfRegistry) Names() []string {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	names := make([]string, 0, len(reg.confs))
+	for name := range reg.confs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RawConfiguration is a configuration of an unknown type. It is serialized to
+// JSON on a best-effort basis, by decoding the protobuf wire format without
+// a schema. Fields are keyed by their number. Length-delimited values are
+// rendered as a string if printable, as a nested message if they can be
+// decoded as one or as base64 encoded bytes otherwise. A field that occurs
+// more than once is rendered as a list.
+type RawConfiguration struct {
+	Raw []byte
+}
+
+var _ gconf.Configuration = (*RawConfiguration)(nil)
+
+func (c *RawConfiguration) Marshal() ([]byte, error) {
+	return c.Raw, nil
+}
+
+func (c *RawConfiguration) Unmarshal(raw []byte) error {
+	c.Raw = append([]byte(nil), raw...)
+	return nil
+}
+
+func (c *RawConfiguration) Validate() error {
+	return nil
+}
+
+func (c *RawConfiguration) MarshalJSON() ([]byte, error) {
+	fields, err := decodeProtoFields(c.Raw)
+	if err != nil {
+		// Not a valid protobuf message, render as base64.
+		return json.Marshal(c.Raw)
+	}
+	return json.Marshal(fields)
+}
+
+// decodeProtoFields decodes protobuf wire format message without a schema.
+func decodeProtoFields(raw []byte) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	for len(raw) > 0 {
+		key, n := binary.Uvarint(raw)
+		if n <= 0 {
+			return nil, errors.Wrap(errors.ErrInput, "invalid field key")
+		}
+		raw = raw[n:]
+		num, wireType := key>>3, key&7
+		if num == 0 {
+			return nil, errors.Wrap(errors.ErrInput, "invalid field number")
+		}
+
+		var value interface{}
+		switch wireType {
+		case 0:
+			v, n := binary.Uvarint(raw)
+			if n <= 0 {
+				return nil, errors.Wrap(errors.ErrInput, "invalid varint")
+			}
+			raw = raw[n:]
+			value = v
+		case 1:
+			if len(raw) < 8 {
+				return nil, errors.Wrap(errors.ErrInput, "invalid fixed64")
+			}
+			value = binary.LittleEndian.Uint64(raw)
+			raw = raw[8:]
+		case 2:
+			size, n := binary.Uvarint(raw)
+			if n <= 0 || uint64(len(raw)-n) < size {
+				return nil, errors.Wrap(errors.ErrInput, "invalid length")
+			}
+			value = decodeProtoBytes(raw[n : n+int(size)])
+			raw = raw[n+int(size):]
+		case 5:
+			if len(raw) < 4 {
+				return nil, errors.Wrap(errors.ErrInput, "invalid fixed32")
+			}
+			value = binary.LittleEndian.Uint32(raw)
+			raw = raw[4:]
+		default:
+			return nil, errors.Wrapf(errors.ErrInput, "unsupported wire type %d", wireType)
+		}
+
+		name := strconv.FormatUint(num, 10)
+		switch prev := fields[name].(type) {
+		case nil:
+			fields[name] = value
+		case []interface{}:
+			fields[name] = append(prev, value)
+		default:
+			fields[name] = []interface{}{prev, value}
+		}
+	}
+	return fields, nil
+}
+
+func decodeProtoBytes(b []byte) interface{} {
+	if isPrintable(b) {
+		return string(b)
+	}
+	if fields, err := decodeProtoFields(b); err == nil {
+		return fields
+	}
+	return b
+}
+
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
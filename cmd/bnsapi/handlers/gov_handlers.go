@@ -0,0 +1,531 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	bnsd "github.com/iov-one/weave/cmd/bnsd/app"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	"github.com/iov-one/weave/x/batch"
+	"github.com/iov-one/weave/x/gov"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// versionedObject is an entity stored in a versioned bucket together with
+// its human readable <id>/<version> reference.
+type versionedObject struct {
+	ID    string    `json:"id"`
+	Value orm.Model `json:"value"`
+}
+
+type GovElectoratesHandler struct {
+	Bns client.BnsClient
+}
+
+// GovElectoratesHandler godoc
+// @Summary Returns a list of x/gov Electorate entities.
+// @Description Each version of an electorate is a separate entity.
+// @Tags Governance
+// @Param offset query int false "Pagination offset"
+// @Success 200 {object} handlers.MultipleObjectsResponse
+// @Failure 400
+// @Failure 500
+// @Router /gov/electorates [get]
+func (h *GovElectoratesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var offset []byte
+	if q.Get("offset") != "" {
+		var err error
+		offset, err = ExtractNumericID(q.Get("offset"))
+		if err != nil && !errors.ErrEmpty.Is(err) {
+			JSONErr(w, http.StatusBadRequest, "offset is in wrong format. send integer")
+			return
+		}
+	}
+
+	it := client.ABCIRangeQuery(r.Context(), h.Bns, "/electorates", fmt.Sprintf("%x:", offset))
+	objects := make([]util.KeyValue, 0, util.PaginationMaxItems)
+fetchElectorates:
+	for {
+		var e gov.Electorate
+		switch key, err := it.Next(&e); {
+		case err == nil:
+			objects = append(objects, util.KeyValue{
+				Key:   key,
+				Value: &e,
+			})
+			if len(objects) == util.PaginationMaxItems {
+				break fetchElectorates
+			}
+		case errors.ErrIteratorDone.Is(err):
+			break fetchElectorates
+		default:
+			log.Printf("gov electorates ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	JSONResp(w, http.StatusOK, MultipleObjectsResponse{
+		Objects: objects,
+	})
+}
+
+type GovElectorateDetailHandler struct {
+	Bns client.BnsClient
+}
+
+// GovElectorateDetailHandler godoc
+// @Summary Returns a single x/gov Electorate entity.
+// @Description The ID can be provided with a version (ex: 1/2). Without a version the latest version is returned.
+// @Tags Governance
+// @Param electorateID path string true "Electorate ID, optionally with a version. ex: 1 or 1/2"
+// @Success 200 {object} handlers.versionedObject
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /gov/electorates/{electorateID} [get]
+func (h *GovElectorateDetailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveVersioned(w, r, h.Bns, "/electorates", PathAfter(r.URL.Path, "/electorates/"),
+		func() orm.Model { return &gov.Electorate{} })
+}
+
+// fetchElectorate loads an electorate. The ID is accepted in any form that
+// fetchVersioned accepts.
+func fetchElectorate(ctx context.Context, bns client.BnsClient, id []byte) (*gov.Electorate, []byte, error) {
+	m, key, err := fetchVersioned(ctx, bns, "/electorates", id, func() orm.Model { return &gov.Electorate{} })
+	if err != nil {
+		return nil, nil, err
+	}
+	return m.(*gov.Electorate), key, nil
+}
+
+// serveVersioned writes the entity of a versioned bucket as a
+// versionedObject. The raw ID is an integer, optionally followed by a
+// version.
+func serveVersioned(w http.ResponseWriter, r *http.Request, bns client.BnsClient, path, rawID string, newModel func() orm.Model) {
+	id, err := ExtractRefID(rawID)
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, "ID must be an integer, optionally followed by a version. ex: 1 or 1/2")
+		return
+	}
+
+	switch m, key, err := fetchVersioned(r.Context(), bns, path, id, newModel); {
+	case err == nil:
+		ref, err := RefKey(key)
+		if err != nil {
+			log.Printf("%s versioned key %q: %s", path, key, err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		JSONResp(w, http.StatusOK, versionedObject{ID: ref, Value: m})
+	case errors.ErrNotFound.Is(err):
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+	default:
+		log.Printf("gov %s ABCI query: %s", path, err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
+
+// fetchVersioned loads an entity of a versioned bucket into a model returned
+// by newModel. The ID is either a versioned ID as returned by ExtractRefID,
+// or a plain 8 bytes ID, in which case the latest version of the entity is
+// loaded.
+func fetchVersioned(ctx context.Context, bns client.BnsClient, path string, id []byte, newModel func() orm.Model) (orm.Model, []byte, error) {
+	if len(id) != 8 {
+		m := newModel()
+		res := models.KeyModel{Model: m}
+		if err := client.ABCIKeyQuery(ctx, bns, path, id, &res); err != nil {
+			return nil, nil, err
+		}
+		return m, res.Key, nil
+	}
+
+	// All versions of an entity share the ID prefix and are ordered by
+	// the version. The last one is the latest.
+	var latestKey []byte
+	var latest orm.Model
+	it := client.ABCIPrefixQuery(ctx, bns, path, id)
+	for {
+		m := newModel()
+		switch key, err := it.Next(m); {
+		case err == nil:
+			latestKey, latest = key, m
+		case errors.ErrIteratorDone.Is(err):
+			if latest == nil {
+				return nil, nil, errors.Wrap(errors.ErrNotFound, "no version")
+			}
+			return latest, latestKey, nil
+		default:
+			return nil, nil, err
+		}
+	}
+}
+
+type GovRulesHandler struct {
+	Bns client.BnsClient
+}
+
+// GovRulesHandler godoc
+// @Summary Returns a list of x/gov ElectionRule entities.
+// @Description Each version of an election rule is a separate entity.
+// @Tags Governance
+// @Param offset query int false "Pagination offset"
+// @Success 200 {object} handlers.MultipleObjectsResponse
+// @Failure 400
+// @Failure 500
+// @Router /gov/rules [get]
+func (h *GovRulesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var offset []byte
+	if q.Get("offset") != "" {
+		var err error
+		offset, err = ExtractNumericID(q.Get("offset"))
+		if err != nil && !errors.ErrEmpty.Is(err) {
+			JSONErr(w, http.StatusBadRequest, "offset is in wrong format. send integer")
+			return
+		}
+	}
+
+	it := client.ABCIRangeQuery(r.Context(), h.Bns, "/electionrules", fmt.Sprintf("%x:", offset))
+	objects := make([]util.KeyValue, 0, util.PaginationMaxItems)
+fetchRules:
+	for {
+		var rule gov.ElectionRule
+		switch key, err := it.Next(&rule); {
+		case err == nil:
+			objects = append(objects, util.KeyValue{
+				Key:   key,
+				Value: &rule,
+			})
+			if len(objects) == util.PaginationMaxItems {
+				break fetchRules
+			}
+		case errors.ErrIteratorDone.Is(err):
+			break fetchRules
+		default:
+			log.Printf("gov election rules ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	JSONResp(w, http.StatusOK, MultipleObjectsResponse{
+		Objects: objects,
+	})
+}
+
+type GovRuleDetailHandler struct {
+	Bns client.BnsClient
+}
+
+// GovRuleDetailHandler godoc
+// @Summary Returns a single x/gov ElectionRule entity.
+// @Description The ID can be provided with a version (ex: 1/2). Without a version the latest version is returned.
+// @Tags Governance
+// @Param ruleID path string true "Election rule ID, optionally with a version. ex: 1 or 1/2"
+// @Success 200 {object} handlers.versionedObject
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /gov/rules/{ruleID} [get]
+func (h *GovRuleDetailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	serveVersioned(w, r, h.Bns, "/electionrules", PathAfter(r.URL.Path, "/rules/"),
+		func() orm.Model { return &gov.ElectionRule{} })
+}
+
+// fetchElectionRule loads an election rule. The ID is accepted in any form
+// that fetchVersioned accepts.
+func fetchElectionRule(ctx context.Context, bns client.BnsClient, id []byte) (*gov.ElectionRule, []byte, error) {
+	m, key, err := fetchVersioned(ctx, bns, "/electionrules", id, func() orm.Model { return &gov.ElectionRule{} })
+	if err != nil {
+		return nil, nil, err
+	}
+	return m.(*gov.ElectionRule), key, nil
+}
+
+type GovElectorateMemberHandler struct {
+	Bns client.BnsClient
+}
+
+// electorateMembership is the membership of an address in an electorate.
+type electorateMembership struct {
+	ElectorateID uint64 `json:"electorate_id"`
+	// Version is the latest version of the electorate.
+	Version uint32 `json:"version"`
+	Weight  uint32 `json:"weight"`
+}
+
+type electorateMembershipsResponse struct {
+	Objects []electorateMembership `json:"objects"`
+}
+
+// GovElectorateMemberHandler godoc
+// @Summary Returns all electorates that given address is an elector of, with the elector weight.
+// @Description Only the latest version of each electorate is considered.
+// @Tags Governance
+// @Param address path string true "Elector address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)"
+// @Success 200 {object} handlers.electorateMembershipsResponse
+// @Failure 400
+// @Failure 500
+// @Router /gov/electorates/member/{address} [get]
+func (h *GovElectorateMemberHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	addr, err := requestAddress(r, LastChunk(r.URL.Path))
+	if err != nil || len(addr) == 0 {
+		JSONErr(w, http.StatusBadRequest, "elector address must be a valid address value")
+		return
+	}
+
+	// The index contains every version of an electorate that the address
+	// was ever part of. Collect unique IDs and check membership in the
+	// latest version only.
+	var ids [][]byte
+	seen := make(map[string]bool)
+	it := client.ABCIKeyQueryIter(r.Context(), h.Bns, "/electorates/elector", addr)
+collectIDs:
+	for {
+		var e gov.Electorate
+		switch key, err := it.Next(&e); {
+		case err == nil:
+			// Skip the bucket prefix, being the characters before : (including separator)
+			ref, err := orm.UnmarshalVersionedID(key[bytes.Index(key, []byte(":"))+1:])
+			if err != nil {
+				log.Printf("electorate versioned key %q: %s", key, err)
+				JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+				return
+			}
+			if !seen[string(ref.ID)] {
+				seen[string(ref.ID)] = true
+				ids = append(ids, ref.ID)
+			}
+		case errors.ErrIteratorDone.Is(err), errors.ErrNotFound.Is(err):
+			break collectIDs
+		default:
+			log.Printf("gov electorate elector ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	memberships := make([]electorateMembership, 0, len(ids))
+	for _, id := range ids {
+		e, key, err := fetchElectorate(r.Context(), h.Bns, id)
+		if err != nil {
+			log.Printf("gov electorate ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		elector, ok := e.Elector(addr)
+		if !ok {
+			continue
+		}
+		// Skip the bucket prefix, being the characters before : (including separator)
+		ref, err := orm.UnmarshalVersionedID(key[bytes.Index(key, []byte(":"))+1:])
+		if err != nil {
+			log.Printf("electorate versioned key %q: %s", key, err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		memberships = append(memberships, electorateMembership{
+			ElectorateID: binary.BigEndian.Uint64(ref.ID),
+			Version:      ref.Version,
+			Weight:       elector.Weight,
+		})
+	}
+
+	JSONResp(w, http.StatusOK, electorateMembershipsResponse{
+		Objects: memberships,
+	})
+}
+
+type GovProposalTallyHandler struct {
+	Bns client.BnsClient
+}
+
+type proposalTally struct {
+	Status       string `json:"status"`
+	Result       string `json:"result"`
+	TotalYes     uint64 `json:"total_yes"`
+	TotalNo      uint64 `json:"total_no"`
+	TotalAbstain uint64 `json:"total_abstain"`
+	// TotalElectorateWeight is the weight of the electorate the proposal
+	// is voted by.
+	TotalElectorateWeight uint64        `json:"total_electorate_weight"`
+	Quorum                *gov.Fraction `json:"quorum,omitempty"`
+	Threshold             gov.Fraction  `json:"threshold"`
+	// WouldPass is true if the proposal would be accepted when tallied
+	// with the current votes.
+	WouldPass     bool           `json:"would_pass"`
+	VotingEndTime weave.UnixTime `json:"voting_end_time"`
+	// TimeLeft is the number of seconds left until the voting period
+	// ends. It is zero once the voting period ended.
+	TimeLeft int64 `json:"time_left"`
+	// NotVoted are the electors that did not vote yet.
+	NotVoted []gov.Elector `json:"not_voted"`
+}
+
+// GovProposalTallyHandler godoc
+// @Summary Returns the current tally of a proposal and whether it would pass if tallied now.
+// @Description Quorum and threshold are taken from the election rule and the total weight from the electorate linked to the proposal.
+// @Tags Governance
+// @Param proposalID path int true "Proposal ID"
+// @Success 200 {object} handlers.proposalTally
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /gov/proposals/{proposalID}/tally [get]
+func (h *GovProposalTallyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rawID := PathAfter(r.URL.Path, "/proposals/")
+	if !strings.HasSuffix(rawID, "/tally") {
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+	id, err := ExtractNumericID(strings.TrimSuffix(rawID, "/tally"))
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, "proposal ID must be an integer")
+		return
+	}
+
+	var p gov.Proposal
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/proposals", id, &models.KeyModel{Model: &p}); {
+	case err == nil:
+	case errors.ErrNotFound.Is(err):
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	default:
+		log.Printf("gov proposal ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	rule, _, err := fetchElectionRule(r.Context(), h.Bns, orm.MarshalVersionedID(p.ElectionRuleRef))
+	if err != nil {
+		log.Printf("gov election rule ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	electorate, _, err := fetchElectorate(r.Context(), h.Bns, orm.MarshalVersionedID(p.ElectorateRef))
+	if err != nil {
+		log.Printf("gov electorate ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	voted := make(map[string]bool)
+	it := client.ABCIKeyQueryIter(r.Context(), h.Bns, "/votes/proposals", id)
+fetchVotes:
+	for {
+		var v gov.Vote
+		switch _, err := it.Next(&v); {
+		case err == nil:
+			voted[string(v.Elector.Address)] = true
+		case errors.ErrIteratorDone.Is(err), errors.ErrNotFound.Is(err):
+			break fetchVotes
+		default:
+			log.Printf("gov votes ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+	notVoted := make([]gov.Elector, 0, len(electorate.Electors))
+	for _, e := range electorate.Electors {
+		if !voted[string(e.Address)] {
+			notVoted = append(notVoted, e)
+		}
+	}
+
+	tally := gov.NewTallyResult(rule.Quorum, rule.Threshold, electorate.TotalElectorateWeight)
+	tally.TotalYes = p.VoteState.TotalYes
+	tally.TotalNo = p.VoteState.TotalNo
+	tally.TotalAbstain = p.VoteState.TotalAbstain
+
+	var timeLeft int64
+	if left := time.Until(p.VotingEndTime.Time()); left > 0 {
+		timeLeft = int64(left / time.Second)
+	}
+
+	JSONResp(w, http.StatusOK, proposalTally{
+		Status:                p.Status.String(),
+		Result:                p.Result.String(),
+		TotalYes:              tally.TotalYes,
+		TotalNo:               tally.TotalNo,
+		TotalAbstain:          tally.TotalAbstain,
+		TotalElectorateWeight: tally.TotalElectorateWeight,
+		Quorum:                tally.Quorum,
+		Threshold:             tally.Threshold,
+		WouldPass:             tally.Accepted(),
+		VotingEndTime:         p.VotingEndTime,
+		TimeLeft:              timeLeft,
+		NotVoted:              notVoted,
+	})
+}
+
+// proposal is a gov.Proposal with its raw option decoded.
+type proposal struct {
+	*gov.Proposal
+	Option *ProposalOption `json:"option,omitempty"`
+}
+
+// ProposalOption is a decoded gov.Proposal RawOption, being the message that
+// is executed when the proposal is accepted.
+type ProposalOption struct {
+	// Path is the path of the message, ex: gov/update_electorate.
+	Path string    `json:"path"`
+	Msg  weave.Msg `json:"msg,omitempty"`
+	// Messages is set instead of Msg for a batch of messages.
+	Messages []ProposalOption `json:"messages,omitempty"`
+}
+
+// DecodeProposalOption decodes a gov.Proposal RawOption, that is a serialized
+// bnsd ProposalOptions.
+func DecodeProposalOption(raw []byte) (*ProposalOption, error) {
+	var opts bnsd.ProposalOptions
+	if err := opts.Unmarshal(raw); err != nil {
+		return nil, errors.Wrap(err, "cannot unmarshal proposal options")
+	}
+	msg, err := weave.ExtractMsgFromSum(opts.Option)
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot extract message")
+	}
+	return newProposalOption(msg)
+}
+
+func newProposalOption(msg weave.Msg) (*ProposalOption, error) {
+	b, ok := msg.(batch.Msg)
+	if !ok {
+		return &ProposalOption{Path: msg.Path(), Msg: msg}, nil
+	}
+	msgs, err := b.MsgList()
+	if err != nil {
+		return nil, errors.Wrap(err, "cannot extract batch messages")
+	}
+	opt := ProposalOption{Path: msg.Path(), Messages: make([]ProposalOption, 0, len(msgs))}
+	for _, m := range msgs {
+		o, err := newProposalOption(m)
+		if err != nil {
+			return nil, err
+		}
+		opt.Messages = append(opt.Messages, *o)
+	}
+	return &opt, nil
+}
+
+// decodeProposal returns the proposal together with its decoded option. An
+// option that cannot be decoded is omitted and the raw value is left to the
+// client.
+func decodeProposal(p *gov.Proposal) *proposal {
+	opt, err := DecodeProposalOption(p.RawOption)
+	if err != nil {
+		log.Printf("proposal option: %s", err)
+	}
+	return &proposal{Proposal: p, Option: opt}
+}
@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	bnsd "github.com/iov-one/weave/cmd/bnsd/app"
+	"github.com/iov-one/weave/orm"
+	"github.com/iov-one/weave/weavetest"
+	"github.com/iov-one/weave/x/batch"
+	"github.com/iov-one/weave/x/gov"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGovElectorateDetailHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+	electorateKey := func(id uint64, version uint32) []byte {
+		ref := orm.VersionedIDRef{ID: EncodeSequence(id), Version: version}
+		return append([]byte("electorate:"), orm.MarshalVersionedID(ref)...)
+	}
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/electorates?prefix": {
+				hexKey(EncodeSequence(1)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{electorateKey(1, 1), electorateKey(1, 2)},
+					[]weave.Persistent{
+						&gov.Electorate{Title: "first"},
+						&gov.Electorate{Title: "second"},
+					}),
+				hexKey(EncodeSequence(2)): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+			"/electorates": {
+				hexKey(orm.MarshalVersionedID(orm.VersionedIDRef{ID: EncodeSequence(1), Version: 1})): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{electorateKey(1, 1)},
+					[]weave.Persistent{&gov.Electorate{Title: "first"}}),
+			},
+		},
+	}
+	h := GovElectorateDetailHandler{Bns: bns}
+
+	cases := map[string]struct {
+		path      string
+		wantCode  int
+		wantID    string
+		wantTitle string
+	}{
+		"latest version":     {path: "/gov/electorates/1", wantCode: http.StatusOK, wantID: "1/2", wantTitle: "second"},
+		"specific version":   {path: "/gov/electorates/1/1", wantCode: http.StatusOK, wantID: "1/1", wantTitle: "first"},
+		"missing electorate": {path: "/gov/electorates/2", wantCode: http.StatusNotFound},
+		"invalid ID":         {path: "/gov/electorates/abc", wantCode: http.StatusBadRequest},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tc.path, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d response, got %d: %s", tc.wantCode, w.Code, w.Body)
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			var res struct {
+				ID    string
+				Value gov.Electorate
+			}
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatalf("cannot decode JSON response: %s", err)
+			}
+			if res.ID != tc.wantID || res.Value.Title != tc.wantTitle {
+				t.Fatalf("want %s %q, got %s %q", tc.wantID, tc.wantTitle, res.ID, res.Value.Title)
+			}
+		})
+	}
+}
+
+func TestGovElectorateMemberHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+	electorateKey := func(id uint64, version uint32) []byte {
+		ref := orm.VersionedIDRef{ID: EncodeSequence(id), Version: version}
+		return append([]byte("electorate:"), orm.MarshalVersionedID(ref)...)
+	}
+
+	member := weavetest.NewCondition().Address()
+	other := weavetest.NewCondition().Address()
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/electorates/elector": {
+				hexKey(member): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{electorateKey(1, 1), electorateKey(2, 1)},
+					[]weave.Persistent{
+						&gov.Electorate{Electors: []gov.Elector{{Address: member, Weight: 3}}},
+						&gov.Electorate{Electors: []gov.Elector{{Address: member, Weight: 1}}},
+					}),
+			},
+			"/electorates?prefix": {
+				hexKey(EncodeSequence(1)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{electorateKey(1, 1), electorateKey(1, 2)},
+					[]weave.Persistent{
+						&gov.Electorate{Electors: []gov.Elector{{Address: member, Weight: 3}}},
+						&gov.Electorate{Electors: []gov.Elector{{Address: member, Weight: 7}}},
+					}),
+				// The member was removed in the latest version.
+				hexKey(EncodeSequence(2)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{electorateKey(2, 1), electorateKey(2, 2)},
+					[]weave.Persistent{
+						&gov.Electorate{Electors: []gov.Elector{{Address: member, Weight: 1}}},
+						&gov.Electorate{Electors: []gov.Elector{{Address: other, Weight: 1}}},
+					}),
+			},
+		},
+	}
+	h := GovElectorateMemberHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/gov/electorates/member/"+member.String(), nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var res electorateMembershipsResponse
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("cannot decode response: %s", err)
+	}
+	want := []electorateMembership{{ElectorateID: 1, Version: 2, Weight: 7}}
+	if !reflect.DeepEqual(res.Objects, want) {
+		t.Fatalf("want %+v, got %+v", want, res.Objects)
+	}
+}
+
+func TestGovProposalTallyHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+	ref := orm.VersionedIDRef{ID: EncodeSequence(1), Version: 1}
+
+	alice := weavetest.NewCondition().Address()
+	bob := weavetest.NewCondition().Address()
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/proposals": {
+				hexKey(EncodeSequence(1)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(1)},
+					[]weave.Persistent{&gov.Proposal{
+						ElectionRuleRef: ref,
+						ElectorateRef:   ref,
+						VoteState:       gov.TallyResult{TotalYes: 2},
+						Status:          gov.Proposal_Submitted,
+						Result:          gov.Proposal_Undefined,
+					}}),
+			},
+			"/electionrules": {
+				hexKey(orm.MarshalVersionedID(ref)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{orm.MarshalVersionedID(ref)},
+					[]weave.Persistent{&gov.ElectionRule{Threshold: gov.Fraction{Numerator: 1, Denominator: 2}}}),
+			},
+			"/electorates": {
+				hexKey(orm.MarshalVersionedID(ref)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{orm.MarshalVersionedID(ref)},
+					[]weave.Persistent{&gov.Electorate{
+						Electors: []gov.Elector{
+							{Address: alice, Weight: 2},
+							{Address: bob, Weight: 1},
+						},
+						TotalElectorateWeight: 3,
+					}}),
+			},
+			"/votes/proposals": {
+				hexKey(EncodeSequence(1)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{append(alice, EncodeSequence(1)...)},
+					[]weave.Persistent{&gov.Vote{
+						Elector: gov.Elector{Address: alice, Weight: 2},
+						Voted:   gov.VoteOption_Yes,
+					}}),
+			},
+		},
+	}
+	h := GovProposalTallyHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/gov/proposals/1/tally", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var tally proposalTally
+	if err := json.NewDecoder(w.Body).Decode(&tally); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if !tally.WouldPass {
+		t.Fatal("want proposal to pass")
+	}
+	if tally.TotalElectorateWeight != 3 || tally.Status != gov.Proposal_Submitted.String() {
+		t.Fatalf("unexpected tally: %+v", tally)
+	}
+	if len(tally.NotVoted) != 1 || !tally.NotVoted[0].Address.Equals(bob) {
+		t.Fatalf("want only bob to not vote, got %+v", tally.NotVoted)
+	}
+}
+
+func TestDecodeProposalOption(t *testing.T) {
+	marshal := func(opts *bnsd.ProposalOptions) []byte {
+		raw, err := opts.Marshal()
+		if err != nil {
+			t.Fatalf("cannot marshal options: %s", err)
+		}
+		return raw
+	}
+
+	text := &gov.CreateTextResolutionMsg{Metadata: &weave.Metadata{Schema: 1}, Resolution: "hello"}
+	cases := map[string]struct {
+		raw       []byte
+		wantPath  string
+		wantBatch []string
+		wantErr   bool
+	}{
+		"text resolution": {
+			raw: marshal(&bnsd.ProposalOptions{
+				Option: &bnsd.ProposalOptions_GovCreateTextResolutionMsg{GovCreateTextResolutionMsg: text},
+			}),
+			wantPath: text.Path(),
+		},
+		"batch": {
+			raw: marshal(&bnsd.ProposalOptions{
+				Option: &bnsd.ProposalOptions_ExecuteProposalBatchMsg{
+					ExecuteProposalBatchMsg: &bnsd.ExecuteProposalBatchMsg{
+						Messages: []bnsd.ExecuteProposalBatchMsg_Union{
+							{Sum: &bnsd.ExecuteProposalBatchMsg_Union_GovCreateTextResolutionMsg{GovCreateTextResolutionMsg: text}},
+						},
+					},
+				},
+			}),
+			wantPath:  batch.PathExecuteBatchMsg,
+			wantBatch: []string{text.Path()},
+		},
+		"invalid": {
+			raw:     []byte("invalid"),
+			wantErr: true,
+		},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			opt, err := DecodeProposalOption(tc.raw)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cannot decode: %s", err)
+			}
+			if opt.Path != tc.wantPath {
+				t.Fatalf("want %q path, got %q", tc.wantPath, opt.Path)
+			}
+			if len(opt.Messages) != len(tc.wantBatch) {
+				t.Fatalf("want %d batch messages, got %d", len(tc.wantBatch), len(opt.Messages))
+			}
+			for i, path := range tc.wantBatch {
+				if opt.Messages[i].Path != path {
+					t.Fatalf("want %q batch message path, got %q", path, opt.Messages[i].Path)
+				}
+			}
+		})
+	}
+}
+
+func TestGovProposalsHandlerDecodesOption(t *testing.T) {
+	text := &gov.CreateTextResolutionMsg{Metadata: &weave.Metadata{Schema: 1}, Resolution: "hello"}
+	raw, err := (&bnsd.ProposalOptions{
+		Option: &bnsd.ProposalOptions_GovCreateTextResolutionMsg{GovCreateTextResolutionMsg: text},
+	}).Marshal()
+	if err != nil {
+		t.Fatalf("cannot marshal options: %s", err)
+	}
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/proposals?range": {
+				"3A": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(1)},
+					[]weave.Persistent{&gov.Proposal{Title: "text", RawOption: raw}}),
+			},
+		},
+	}
+	h := GovProposalsHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/gov/proposals", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var res struct {
+		Objects []struct {
+			Value struct {
+				Title  string
+				Option struct {
+					Path string
+					Msg  gov.CreateTextResolutionMsg
+				}
+			}
+		}
+	}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if len(res.Objects) != 1 {
+		t.Fatalf("want one proposal, got %d", len(res.Objects))
+	}
+	p := res.Objects[0].Value
+	if p.Title != "text" || p.Option.Path != text.Path() || p.Option.Msg.Resolution != "hello" {
+		t.Fatalf("unexpected proposal: %+v", p)
+	}
+}
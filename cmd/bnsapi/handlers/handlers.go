@@ -2,17 +2,14 @@ package handlers
 
 import (
 	"encoding/base64"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/iov-one/bns/cmd/bnsapi/models"
-	weavecrypto "github.com/iov-one/weave/crypto"
 	"github.com/iov-one/weave/x/msgfee"
 	"github.com/iov-one/weave/x/sigs"
 	"html/template"
 	"log"
 	"net/http"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -21,7 +18,6 @@ import (
 	"github.com/iov-one/weave/x/cash"
 
 	"github.com/iov-one/weave/errors"
-	"github.com/iov-one/weave/gconf"
 	"github.com/iov-one/weave/x/escrow"
 	"github.com/iov-one/weave/x/gov"
 	"github.com/iov-one/weave/x/multisig"
@@ -34,6 +30,7 @@ type GovProposalsHandler struct {
 // GovProposalsHandler godoc
 // @Summary Returns a list of x/gov Votes entities.
 // @Description At most one of the query parameters must exist(excluding offset)
+// @Description Proposal raw option is decoded into the option field, being the message executed if the proposal is accepted.
 // @Tags Governance
 // @Param author query string false "Author address"
 // @Param electorate query string false "Base64 encoded electorate ID"
@@ -82,7 +79,7 @@ func (h *GovProposalsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		end := NextKeyValue(start)
 		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/proposals/electorate", fmt.Sprintf("%x:%x:%x", start, offset, end))
 	} else if s := q.Get("author"); len(s) > 0 {
-		rawAddr, err := WeaveAddressFromQuery(s)
+		rawAddr, err := requestAddress(r, s)
 		if err != nil {
 			JSONErr(w, http.StatusBadRequest, "author address must be a valid address value.")
 			return
@@ -103,7 +100,7 @@ fetchProposals:
 		case err == nil:
 			objects = append(objects, util.KeyValue{
 				Key:   key,
-				Value: &p,
+				Value: decodeProposal(&p),
 			})
 			if len(objects) == util.PaginationMaxItems {
 				break fetchProposals
@@ -160,7 +157,7 @@ func (h *GovVotesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var it client.ABCIIterator
 	if e := q.Get("elector"); len(e) > 0 {
-		rawAddr, err := WeaveAddressFromQuery(e)
+		rawAddr, err := requestAddress(r, e)
 		if err != nil {
 			JSONErr(w, http.StatusBadRequest, "elector ID address must be a valid address value..")
 			return
@@ -178,7 +175,7 @@ func (h *GovVotesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		end := NextKeyValue(start)
 		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/votes/electors", fmt.Sprintf("%x:%x:%x", start, offset, end))
 	} else if p := q.Get("proposal"); len(p) > 0 {
-		rawAddr, err := WeaveAddressFromQuery(p)
+		rawAddr, err := requestAddress(r, p)
 		if err != nil {
 			JSONErr(w, http.StatusBadRequest, "proposal ID address must be a valid address value..")
 			return
@@ -257,7 +254,7 @@ func (h *EscrowEscrowsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 
 	var it client.ABCIIterator
 	if d := q.Get("destination"); len(d) > 0 {
-		rawAddr, err := WeaveAddressFromQuery(d)
+		rawAddr, err := requestAddress(r, d)
 		if err != nil {
 			JSONErr(w, http.StatusBadRequest, "Destination address must be a valid address value..")
 			return
@@ -265,7 +262,7 @@ func (h *EscrowEscrowsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 		end := NextKeyValue(rawAddr)
 		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/escrows/destination", fmt.Sprintf("%s:%x:%x", rawAddr, offset, end))
 	} else if s := q.Get("source"); len(s) > 0 {
-		rawAddr, err := WeaveAddressFromQuery(s)
+		rawAddr, err := requestAddress(r, s)
 		if err != nil {
 			JSONErr(w, http.StatusBadRequest, "Source address must be a valid address value..")
 			return
@@ -360,20 +357,13 @@ fetchContracts:
 
 type GconfHandler struct {
 	Bns   client.BnsClient
-	Confs map[string]func() gconf.Configuration
-}
-
-func (h *GconfHandler) knownConfigurations() []string {
-	known := make([]string, 0, len(h.Confs))
-	for name := range h.Confs {
-		known = append(known, name)
-	}
-	sort.Strings(known)
-	return known
+	Confs *GconfRegistry
 }
 
 // GconfHandler godoc
 // @Summary Get configuration with extension name
+// @Description Configurations of extensions that are not registered are decoded on a best-effort basis,
+// @Description with the protobuf fields keyed by their number.
 // @Tags Status
 // @Param extensionName path string true "Extension name"
 // @Success 200 {object} gconf.Configuration
@@ -381,21 +371,21 @@ func (h *GconfHandler) knownConfigurations() []string {
 // @Failure 500
 // @Router /gconf/{extensionName} [get]
 func (h *GconfHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/history") {
+		h.serveHistory(w, r)
+		return
+	}
+
 	extensionName := LastChunk(r.URL.Path)
 	if extensionName == "" {
 		JSONErr(w, http.StatusNotFound,
-			fmt.Sprintf("Extension name must be provided. Supported extensions are %q", h.knownConfigurations()))
+			fmt.Sprintf("Extension name must be provided. Supported extensions are %q", h.Confs.Names()))
 		return
 	}
 
-	var conf gconf.Configuration
-	if fn, ok := h.Confs[extensionName]; ok {
-		conf = fn()
-	} else {
-		log.Printf("extension %q gconf configuration entity unknown to gconf handler", extensionName)
-		JSONErr(w, http.StatusNotFound,
-			fmt.Sprintf("Configuration not registered for browsing. Supported extensions are %q", h.knownConfigurations()))
-		return
+	conf, ok := h.Confs.New(extensionName)
+	if !ok {
+		log.Printf("extension %q gconf configuration entity unknown to gconf handler, decoding raw", extensionName)
 	}
 
 	res := models.KeyModel{
@@ -465,32 +455,64 @@ func (h *BlocksHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 // DefaultHandler is used to handle the request that no other handler wants.
-type DefaultHandler struct{}
+type DefaultHandler struct {
+	// Prefix is the path that the API is mounted under, if any.
+	Prefix string
+}
 
 var wEndpoint = []string{
 	"/account/accounts?owner=_&domain=_&offset_",
 	"/account/domains?admin=_&offset=_",
 	"/account/resolve/{starname}",
+	"/account/resolve/{starname}/history",
 	"/account/accounts/{accountKey}",
 	"/nonce/address/{address}",
-	"/nonce/pubkey/{pubKey}",
+	"/nonce/pubkey/{pubKey}?type=_",
+	"/address/convert/{value}",
 	"/cash/balances?address=_[OR]offset=_",
+	"/cash/supply?height=_",
+	"/cash/holders?ticker=_&top=_&height=_",
+	"/currency/tokens?ticker=_",
 	"/msgfee/msgfee?msgfee=_",
+	"/preregistration/records?domain=_[OR]offset=_",
 	"/username/resolve/{username}",
 	"/username/owner/{ownerAddress}",
+	"/username/reverse?blockchain_id=_&address=_",
+	"/username/usernames?owner=_&offset=_&limit=_",
+	"/username/{username}/account",
 	"/escrow/escrows?source=_&destination=_&offset=_",
+	"/escrow/escrows/{escrowID}",
 	"/multisig/contracts?prefix=_",
+	"/multisig/contracts/{contractID}",
+	"/multisig/participant/{address}",
+	"/portfolio/{address}",
 	"/termdeposit/contracts?offset=_",
+	"/termdeposit/contracts/{contractID}/summary",
+	"/termdeposit/depositors/{address}/summary",
 	"/termdeposit/deposits?depositor=_&contract=_&contract_id=?_offset=_",
+	"/termdeposit/deposits/{depositID}",
+	"/termdeposit/quote?contract_id=_&amount=_&ticker=_",
+	"/gconf?height=_",
 	"/gconf/{extensionName}",
+	"/gconf/{extensionName}/history?from=_&to=_",
 	"/blocks/{blockHeight}",
 	"/gov/proposals?author=_&electorate=_&electorate_id=_&offset=_",
+	"/gov/proposals/{proposalID}/tally",
 	"/gov/votes?proposal=_&proposal_id=&elector=_&elector_id=_&offset=_",
+	"/gov/electorates?offset=_",
+	"/gov/electorates/{electorateID}",
+	"/gov/electorates/member/{address}",
+	"/gov/rules?offset=_",
+	"/gov/rules/{ruleID}",
+	"/export/{collection}?format=_",
 }
 
 var withoutParamEndpoint = []string{
 	"/info/",
 	"/tx/submit",
+	"/auth/challenge",
+	"/auth/verify",
+	"/migration/schemas",
 }
 
 type endpoints struct {
@@ -519,7 +541,7 @@ func (h *DefaultHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// No trailing slash.
 	if len(r.URL.Path) > 1 && r.URL.Path[len(r.URL.Path)-1] == '/' {
 		path := strings.TrimRight(r.URL.Path, "/")
-		JSONRedirect(w, http.StatusPermanentRedirect, path)
+		JSONRedirect(w, http.StatusPermanentRedirect, h.Prefix+path)
 		return
 	}
 
@@ -556,7 +578,7 @@ func (h *CashBalanceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	key := q.Get("address")
 	if key != "" {
-		addr, err := WeaveAddressFromQuery(key)
+		addr, err := requestAddress(r, key)
 		if err != nil {
 			log.Print(err)
 			JSONErr(w, http.StatusBadRequest, http.StatusText(http.StatusBadRequest))
@@ -622,7 +644,7 @@ type NonceAddressHandler struct {
 // @Router /nonce/address/{address} [get]
 func (h *NonceAddressHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	addressStr := LastChunk(r.URL.Path)
-	addr, err := WeaveAddressFromQuery(addressStr)
+	addr, err := requestAddress(r, addressStr)
 	if err != nil {
 		JSONErr(w, http.StatusBadRequest, "provide a weave address")
 		return
@@ -647,30 +669,47 @@ type NoncePubKeyHandler struct {
 	Bns client.BnsClient
 }
 
+type noncePubKeyResponse struct {
+	models.KeyModel
+	Address AddressForms `json:"address"`
+}
+
 // NonceAddressHandler godoc
 // @Summary Returns nonce based on an address
 // @Description Returns nonce and public key registered for a given pubkey if it was ever used.
+// @Description The public key can be hex, bech32 or base64 encoded. Only ed25519 keys are
+// @Description accepted, because weave verifies signatures with ed25519 keys only. Secp256k1
+// @Description keys are rejected.
 // @Param pubKey path string true "Public key to query for nonce. ex: 12ee6f581fe55673a1e9e1382a0829e32075a0aa4763c968bc526e1852e78c95"
+// @Param type query string false "Public key type, only ed25519 is supported"
 // @Tags Nonce
 // @Success 200
+// @Failure 400
 // @Failure 404
 // @Failure 500
 // @Router /nonce/pubkey/{pubKey} [get]
 func (h *NoncePubKeyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	pubKeyStr := LastChunk(r.URL.Path)
-	hexKey, err := hex.DecodeString(pubKeyStr)
+	// Base64 encoded keys may contain a slash.
+	rawKey, err := DecodePubKey(PathAfter(r.URL.Path, "/nonce/pubkey/"))
 	if err != nil {
-		JSONErr(w, http.StatusBadRequest, "please provide a hex public key")
+		JSONErr(w, http.StatusBadRequest, "please provide a hex, bech32 or base64 encoded public key")
 		return
 	}
-	pubKey := weavecrypto.PublicKey_Ed25519{Ed25519: hexKey}
-	addr := pubKey.Condition().Address()
+	cond, err := PubKeyCondition(r.URL.Query().Get("type"), rawKey)
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	addr := cond.Address()
 
 	var userData sigs.UserData
-	res := models.KeyModel{
-		Model: &userData,
+	res := noncePubKeyResponse{
+		KeyModel: models.KeyModel{
+			Model: &userData,
+		},
+		Address: NewAddressForms(addr),
 	}
-	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/auth", addr, &res); {
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/auth", addr, &res.KeyModel); {
 	case err == nil:
 		JSONResp(w, http.StatusOK, res)
 	case errors.ErrNotFound.Is(err):
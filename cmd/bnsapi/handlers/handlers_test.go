@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	weavecrypto "github.com/iov-one/weave/crypto"
+	"github.com/iov-one/weave/x/sigs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNoncePubKeyHandler(t *testing.T) {
+	pub := weavecrypto.GenPrivKeyEd25519().PublicKey()
+	addr := pub.Address()
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/auth": {
+				strings.ToUpper(hex.EncodeToString(addr)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{addr},
+					[]weave.Persistent{&sigs.UserData{Pubkey: pub, Sequence: 7}}),
+			},
+		},
+	}
+	h := NoncePubKeyHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/nonce/pubkey/"+hex.EncodeToString(pub.GetEd25519()), nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var res struct {
+		Key   []byte
+		Model struct {
+			Sequence int64
+		}
+		Address AddressForms
+	}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("cannot decode response: %s", err)
+	}
+	if !weave.Address(res.Key).Equals(addr) {
+		t.Fatalf("want %s key, got %X", addr, res.Key)
+	}
+	if res.Model.Sequence != 7 {
+		t.Fatalf("want sequence 7, got %d", res.Model.Sequence)
+	}
+	if want := NewAddressForms(addr); res.Address != want {
+		t.Fatalf("want %+v address, got %+v", want, res.Address)
+	}
+}
+
+func TestNoncePubKeyHandlerBase64WithSlash(t *testing.T) {
+	raw := make([]byte, 32)
+	raw[1], raw[2] = 0x0f, 0xc0
+	pub := &weavecrypto.PublicKey{Pub: &weavecrypto.PublicKey_Ed25519{Ed25519: raw}}
+	addr := pub.Address()
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/auth": {
+				strings.ToUpper(hex.EncodeToString(addr)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{addr},
+					[]weave.Persistent{&sigs.UserData{Pubkey: pub, Sequence: 2}}),
+			},
+		},
+	}
+	h := NoncePubKeyHandler{Bns: bns}
+
+	key := base64.StdEncoding.EncodeToString(raw)
+	if !strings.Contains(key, "/") {
+		t.Fatalf("test key %q must contain a slash", key)
+	}
+	r, _ := http.NewRequest("GET", "/nonce/pubkey/"+key, nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestNoncePubKeyHandlerRejectsSecp256k1(t *testing.T) {
+	h := NoncePubKeyHandler{Bns: &bnsapitest.BnsClientMock{}}
+
+	key := "02" + strings.Repeat("34", 32)
+	r, _ := http.NewRequest("GET", "/nonce/pubkey/"+key+"?type=secp256k1", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("want 400 response, got %d: %s", w.Code, w.Body)
+	}
+}
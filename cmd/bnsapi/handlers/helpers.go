@@ -2,17 +2,22 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"github.com/iov-one/bns/cmd/bnsapi/util"
 	"github.com/iov-one/weave"
+	weavecrypto "github.com/iov-one/weave/crypto"
+	"github.com/iov-one/weave/crypto/bech32"
 	"github.com/iov-one/weave/errors"
 	"github.com/iov-one/weave/orm"
 	"log"
 	"math"
 	"net/http"
 	"net/url"
+	"reflect"
 	"strconv"
 	"strings"
 )
@@ -71,11 +76,7 @@ func ExtractRefID(s string) ([]byte, error) {
 }
 
 func ExtractAddress(rawAddr string) ([]byte, error) {
-	if strings.HasPrefix(rawAddr, "iov") || strings.HasPrefix(rawAddr, "tiov") {
-		rawAddr = "bech32:" + rawAddr
-	}
-	addr, err := weave.ParseAddress(rawAddr)
-	return addr, err
+	return WeaveAddressFromQuery(rawAddr)
 }
 
 func ExtractStrID(s string) ([]byte, error) {
@@ -131,6 +132,9 @@ func ExtractOffsetFromParam(param string) ([]byte, error) {
 
 // JSONResp write content as JSON encoded response.
 func JSONResp(w http.ResponseWriter, code int, content interface{}) {
+	if rw, ok := w.(*renderWriter); ok {
+		content = rw.render(reflect.ValueOf(content))
+	}
 	b, err := json.MarshalIndent(content, "", "\t")
 	if err != nil {
 		log.Printf("cannot JSON serialize response: %s", err)
@@ -192,6 +196,8 @@ func NextKeyValue(b []byte) []byte {
 	return next
 }
 
+// WeaveAddressFromQuery parses an address in any of the weave formats. Bech32
+// addresses can be provided without the format prefix.
 func WeaveAddressFromQuery(rawAddr string) (weave.Address, error) {
 	if strings.HasPrefix(rawAddr, "iov") || strings.HasPrefix(rawAddr, "tiov") {
 		rawAddr = "bech32:" + rawAddr
@@ -216,3 +222,69 @@ func LastChunk(path string) string {
 	}
 	return path
 }
+
+// AddressForms holds all common representations of a weave address.
+type AddressForms struct {
+	Hex           string `json:"hex"`
+	Bech32Mainnet string `json:"bech32_mainnet"`
+	Bech32Testnet string `json:"bech32_testnet"`
+}
+
+// NewAddressForms returns hex and bech32 representations of given address.
+// Bech32 representation is provided for both the main net (iov) and the test
+// net (tiov).
+func NewAddressForms(addr weave.Address) AddressForms {
+	mainnet, _ := addr.Bech32String("iov")
+	testnet, _ := addr.Bech32String("tiov")
+	return AddressForms{
+		Hex:           addr.String(),
+		Bech32Mainnet: mainnet,
+		Bech32Testnet: testnet,
+	}
+}
+
+// DecodePubKey decodes a public key that is either hex, bech32 or base64
+// encoded. Encodings are tried in that order.
+func DecodePubKey(s string) ([]byte, error) {
+	if s == "" {
+		return nil, errors.Wrap(errors.ErrEmpty, "public key")
+	}
+	if raw, err := hex.DecodeString(s); err == nil {
+		return raw, nil
+	}
+	if _, raw, err := bech32.Decode(s); err == nil {
+		return raw, nil
+	}
+	if raw, err := base64.StdEncoding.DecodeString(s); err == nil {
+		return raw, nil
+	}
+	if raw, err := base64.URLEncoding.DecodeString(s); err == nil {
+		return raw, nil
+	}
+	return nil, errors.Wrap(errors.ErrInput, "unknown public key encoding")
+}
+
+// PubKeyCondition returns the signature condition for a public key of given
+// type. If the type is empty, ed25519 is assumed. weave signatures are
+// verified with ed25519 keys only, so any other key type is rejected.
+func PubKeyCondition(keyType string, raw []byte) (weave.Condition, error) {
+	if keyType != "" && keyType != "ed25519" {
+		return nil, errors.Wrapf(errors.ErrInput, "unsupported public key type %q, only ed25519 is supported", keyType)
+	}
+	if len(raw) != 32 {
+		return nil, errors.Wrap(errors.ErrInput, "ed25519 public key must be 32 bytes long")
+	}
+	pubKey := weavecrypto.PublicKey_Ed25519{Ed25519: raw}
+	return pubKey.Condition(), nil
+}
+
+// PathAfter returns everything in the path that follows the first occurrence
+// of the marker. For example foo/bar for /convert/foo/bar and /convert/ marker.
+// Use it instead of LastChunk when the value may contain `/` characters.
+func PathAfter(path, marker string) string {
+	i := strings.Index(path, marker)
+	if i < 0 {
+		return ""
+	}
+	return path[i+len(marker):]
+}
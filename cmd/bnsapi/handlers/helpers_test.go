@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/crypto/bech32"
+	"testing"
+)
+
+func TestPubKeyCondition(t *testing.T) {
+	ed25519Key := bytes.Repeat([]byte{0x12}, 32)
+	secpKey := append([]byte{0x02}, bytes.Repeat([]byte{0x34}, 32)...)
+	uncompressedSecpKey := append([]byte{0x04}, bytes.Repeat([]byte{0x34}, 64)...)
+	bechKey, err := bech32.Encode("pubkey", ed25519Key)
+	if err != nil {
+		t.Fatalf("cannot bech32 encode: %s", err)
+	}
+
+	cases := map[string]struct {
+		encoded  string
+		keyType  string
+		wantCond weave.Condition
+		wantErr  bool
+	}{
+		"hex ed25519": {
+			encoded:  hex.EncodeToString(ed25519Key),
+			wantCond: weave.NewCondition("sigs", "ed25519", ed25519Key),
+		},
+		"bech32 ed25519": {
+			encoded:  string(bechKey),
+			wantCond: weave.NewCondition("sigs", "ed25519", ed25519Key),
+		},
+		"base64 ed25519 explicit type": {
+			encoded:  base64.StdEncoding.EncodeToString(ed25519Key),
+			keyType:  "ed25519",
+			wantCond: weave.NewCondition("sigs", "ed25519", ed25519Key),
+		},
+		"compressed secp256k1": {
+			encoded: hex.EncodeToString(secpKey),
+			wantErr: true,
+		},
+		"uncompressed secp256k1": {
+			encoded: hex.EncodeToString(uncompressedSecpKey),
+			wantErr: true,
+		},
+		"secp256k1 explicit type": {
+			encoded: hex.EncodeToString(secpKey),
+			keyType: "secp256k1",
+			wantErr: true,
+		},
+		"unknown type": {
+			encoded: hex.EncodeToString(ed25519Key),
+			keyType: "rsa",
+			wantErr: true,
+		},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			raw, err := DecodePubKey(tc.encoded)
+			if err != nil {
+				t.Fatalf("cannot decode: %s", err)
+			}
+			cond, err := PubKeyCondition(tc.keyType, raw)
+			if hasErr := err != nil; hasErr != tc.wantErr {
+				t.Fatalf("want error %v, got %v", tc.wantErr, err)
+			}
+			if !cond.Equals(tc.wantCond) {
+				t.Fatalf("want %s condition, got %s", tc.wantCond, cond)
+			}
+		})
+	}
+}
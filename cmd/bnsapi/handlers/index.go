@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/indexer"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	"log"
+	"sync"
+	"time"
+)
+
+// indexBuildTimeout limits a single build of a locally built index.
+const indexBuildTimeout = 5 * time.Minute
+
+// ttlIndex holds a value built locally by reading the bnsd state, for
+// lookups that bnsd does not index. The value is rebuilt in the background
+// once older than the ttl and the stale value is served until the rebuild
+// completes. Only the very first lookup waits for the build.
+//
+// The build runs with its own context, so that a cancelled request does not
+// abort it for everyone else.
+type ttlIndex struct {
+	ttl   time.Duration
+	build func(context.Context) (interface{}, error)
+
+	mu      sync.Mutex
+	value   interface{}
+	builtAt time.Time
+	// building is closed when the build in progress completes. It is nil
+	// if no build is running.
+	building chan struct{}
+	err      error
+}
+
+func newTTLIndex(ttl time.Duration, build func(context.Context) (interface{}, error)) *ttlIndex {
+	return &ttlIndex{ttl: ttl, build: build}
+}
+
+// get returns the index value, starting a rebuild if it is older than the
+// ttl. It blocks only if the value was never built.
+func (ix *ttlIndex) get(ctx context.Context) (interface{}, error) {
+	ix.mu.Lock()
+	if ix.value != nil && time.Since(ix.builtAt) <= ix.ttl {
+		value := ix.value
+		ix.mu.Unlock()
+		return value, nil
+	}
+	if ix.building == nil {
+		ix.building = make(chan struct{})
+		go ix.rebuild(ix.building)
+	}
+	if ix.value != nil {
+		value := ix.value
+		ix.mu.Unlock()
+		return value, nil
+	}
+	building := ix.building
+	ix.mu.Unlock()
+
+	select {
+	case <-building:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if ix.value == nil {
+		return nil, ix.err
+	}
+	return ix.value, nil
+}
+
+func (ix *ttlIndex) rebuild(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), indexBuildTimeout)
+	defer cancel()
+	value, err := ix.build(ctx)
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if err != nil {
+		if ix.value != nil {
+			log.Printf("index rebuild, serving stale index: %s", err)
+		}
+	} else {
+		ix.value = value
+		ix.builtAt = time.Now()
+	}
+	ix.err = err
+	ix.building = nil
+	close(done)
+}
+
+// indexedObjects returns the entities that the indexer maps given value to.
+// All entities are read at the height of the last block processed by the
+// indexer, so that they are consistent with the index.
+func indexedObjects(
+	ctx context.Context,
+	bns client.BnsClient,
+	ix *indexer.Indexer,
+	index string,
+	value []byte,
+	path string,
+	newModel func() orm.Model,
+) ([]util.KeyValue, error) {
+	keys, height, err := ix.Lookup(index, value)
+	if err != nil {
+		return nil, errors.Wrap(err, "lookup")
+	}
+	ctx = client.WithHeight(ctx, height)
+	objects := make([]util.KeyValue, 0, len(keys))
+	for _, key := range keys {
+		m := newModel()
+		res := models.KeyModel{Model: m}
+		switch err := client.ABCIKeyQuery(ctx, bns, path, key, &res); {
+		case err == nil:
+			objects = append(objects, util.KeyValue{Key: res.Key, Value: m})
+		case errors.ErrNotFound.Is(err):
+			// The node did not return the indexed entity, which is
+			// not a reason to fail the whole lookup.
+		default:
+			return nil, errors.Wrapf(err, "%s %q", path, key)
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTTLIndex(t *testing.T) {
+	builds := make(chan int)
+	ix := newTTLIndex(time.Hour, func(ctx context.Context) (interface{}, error) {
+		select {
+		case n := <-builds:
+			return n, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	// A cancelled request does not abort the build for other callers.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ix.get(ctx); err != context.Canceled {
+		t.Fatalf("want cancelled request to fail, got %v", err)
+	}
+	builds <- 1
+	if v, err := ix.get(context.Background()); err != nil || v != 1 {
+		t.Fatalf("want first build, got %v, %v", v, err)
+	}
+
+	// An expired index is served while being rebuilt.
+	ix.mu.Lock()
+	ix.builtAt = time.Time{}
+	ix.mu.Unlock()
+	if v, err := ix.get(context.Background()); err != nil || v != 1 {
+		t.Fatalf("want stale value, got %v, %v", v, err)
+	}
+	builds <- 2
+	deadline := time.Now().Add(time.Second)
+	for {
+		v, err := ix.get(context.Background())
+		if err != nil {
+			t.Fatalf("get: %s", err)
+		}
+		if v == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("index was not rebuilt")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
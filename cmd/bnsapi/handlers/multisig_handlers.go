@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"context"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/indexer"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/multisig"
+	"log"
+	"net/http"
+	"time"
+)
+
+type MultisigContractDetailHandler struct {
+	Bns client.BnsClient
+}
+
+type multisigContractDetail struct {
+	Contract  *multisig.Contract `json:"contract"`
+	Condition weave.Condition    `json:"condition"`
+	Address   weave.Address      `json:"address"`
+	Balance   *cash.Set          `json:"balance"`
+	// TotalWeight is the combined weight of all participants.
+	TotalWeight multisig.Weight `json:"total_weight"`
+	// CanActivate is true if all participants together reach the
+	// activation threshold.
+	CanActivate bool `json:"can_activate"`
+	// CanAdmin is true if all participants together reach the admin
+	// threshold.
+	CanAdmin bool `json:"can_admin"`
+}
+
+// MultisigContractDetailHandler godoc
+// @Summary Returns a multisig contract together with its address, balance and participant weight.
+// @Tags IOV token
+// @Param contractID path int true "Contract ID"
+// @Success 200 {object} handlers.multisigContractDetail
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /multisig/contracts/{contractID} [get]
+func (h *MultisigContractDetailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := ExtractNumericID(LastChunk(r.URL.Path))
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, "contract ID must be an integer")
+		return
+	}
+
+	var c multisig.Contract
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/contracts", id, &models.KeyModel{Model: &c}); {
+	case err == nil:
+	case errors.ErrNotFound.Is(err):
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	default:
+		log.Printf("multisig contract ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	cond := multisig.MultiSigCondition(id)
+	balance, err := walletBalance(r.Context(), h.Bns, cond.Address())
+	if err != nil {
+		log.Printf("multisig wallet ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	var total multisig.Weight
+	for _, p := range c.Participants {
+		total += p.Weight
+	}
+
+	JSONResp(w, http.StatusOK, multisigContractDetail{
+		Contract:    &c,
+		Condition:   cond,
+		Address:     cond.Address(),
+		Balance:     balance,
+		TotalWeight: total,
+		CanActivate: total >= c.ActivationThreshold,
+		CanAdmin:    total >= c.AdminThreshold,
+	})
+}
+
+// MultisigParticipants returns multisig contracts that given address
+// participates in.
+type MultisigParticipants interface {
+	Contracts(ctx context.Context, addr weave.Address) ([]util.KeyValue, error)
+}
+
+type MultisigParticipantHandler struct {
+	Index MultisigParticipants
+}
+
+// MultisigParticipantHandler godoc
+// @Summary Returns a list of all multisig contracts that given address participates in.
+// @Description The list is served from a local index that is either refreshed periodically or kept up to date by following new blocks.
+// @Tags IOV token
+// @Param address path string true "Participant address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)"
+// @Success 200 {object} handlers.MultipleObjectsResponse
+// @Failure 400
+// @Failure 500
+// @Failure 503
+// @Router /multisig/participant/{address} [get]
+func (h *MultisigParticipantHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	addr, err := requestAddress(r, LastChunk(r.URL.Path))
+	if err != nil || len(addr) == 0 {
+		JSONErr(w, http.StatusBadRequest, "participant address must be a valid address value")
+		return
+	}
+
+	objects, err := h.Index.Contracts(r.Context(), addr)
+	switch {
+	case err == nil:
+	case errors.ErrState.Is(err):
+		JSONErr(w, http.StatusServiceUnavailable, "index is not built yet")
+		return
+	default:
+		log.Printf("multisig participant index: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	JSONResp(w, http.StatusOK, MultipleObjectsResponse{
+		Objects: objects,
+	})
+}
+
+// MultisigParticipantIndex maps participant addresses to the multisig
+// contracts they take part in. bnsd does not index contract participants, so
+// the index is built locally by reading all contracts. It is rebuilt when
+// older than the configured ttl.
+type MultisigParticipantIndex struct {
+	index *ttlIndex
+}
+
+// NewMultisigParticipantIndex returns an index that is rebuilt at most once
+// every ttl.
+func NewMultisigParticipantIndex(bns client.BnsClient, ttl time.Duration) *MultisigParticipantIndex {
+	return &MultisigParticipantIndex{
+		index: newTTLIndex(ttl, func(ctx context.Context) (interface{}, error) {
+			return buildParticipantIndex(ctx, bns)
+		}),
+	}
+}
+
+// Contracts returns all contracts that given address participates in.
+func (ix *MultisigParticipantIndex) Contracts(ctx context.Context, addr weave.Address) ([]util.KeyValue, error) {
+	byAddr, err := ix.index.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	contracts := byAddr.(map[string][]util.KeyValue)[string(addr)]
+	if contracts == nil {
+		contracts = []util.KeyValue{}
+	}
+	return contracts, nil
+}
+
+// IndexedMultisigParticipants serves multisig participants from the indexer.
+type IndexedMultisigParticipants struct {
+	Bns     client.BnsClient
+	Indexer *indexer.Indexer
+}
+
+// Contracts returns all contracts that given address participates in, as of
+// the last block processed by the indexer.
+func (ix *IndexedMultisigParticipants) Contracts(ctx context.Context, addr weave.Address) ([]util.KeyValue, error) {
+	return indexedObjects(ctx, ix.Bns, ix.Indexer, indexer.MultisigParticipantIndex, addr, "/contracts",
+		func() orm.Model { return &multisig.Contract{} })
+}
+
+func buildParticipantIndex(ctx context.Context, bns client.BnsClient) (map[string][]util.KeyValue, error) {
+	byAddr := make(map[string][]util.KeyValue)
+	it := client.ABCIFullRangeQuery(ctx, bns, "/contracts", "")
+	for {
+		var c multisig.Contract
+		switch key, err := it.Next(&c); {
+		case err == nil:
+			seen := make(map[string]bool)
+			for _, p := range c.Participants {
+				a := string(p.Signature)
+				if seen[a] {
+					continue
+				}
+				seen[a] = true
+				contract := c
+				byAddr[a] = append(byAddr[a], util.KeyValue{
+					Key:   key,
+					Value: &contract,
+				})
+			}
+		case errors.ErrIteratorDone.Is(err):
+			return byAddr, nil
+		default:
+			return nil, errors.Wrap(err, "contracts")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/x/multisig"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMultisigContractDetailHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+
+	alice := weave.NewCondition("sigs", "ed25519", []byte("alice")).Address()
+	bob := weave.NewCondition("sigs", "ed25519", []byte("bob")).Address()
+	contract := &multisig.Contract{
+		Participants: []*multisig.Participant{
+			{Signature: alice, Weight: 1},
+			{Signature: bob, Weight: 2},
+		},
+		ActivationThreshold: 2,
+		AdminThreshold:      4,
+	}
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/contracts": {
+				hexKey(EncodeSequence(1)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(1)},
+					[]weave.Persistent{contract}),
+			},
+			"/wallets": {
+				hexKey(multisig.MultiSigCondition(EncodeSequence(1)).Address()): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+		},
+	}
+	h := MultisigContractDetailHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/multisig/contracts/1", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var detail multisigContractDetail
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if detail.TotalWeight != 3 || !detail.CanActivate || detail.CanAdmin {
+		t.Fatalf("unexpected weight computation: %+v", detail)
+	}
+	if want := multisig.MultiSigCondition(EncodeSequence(1)).Address(); !detail.Address.Equals(want) {
+		t.Fatalf("want %s address, got %s", want, detail.Address)
+	}
+}
+
+func TestMultisigParticipantHandler(t *testing.T) {
+	alice := weave.NewCondition("sigs", "ed25519", []byte("alice")).Address()
+	bob := weave.NewCondition("sigs", "ed25519", []byte("bob")).Address()
+	first := &multisig.Contract{
+		Participants: []*multisig.Participant{{Signature: alice, Weight: 1}},
+	}
+	second := &multisig.Contract{
+		Participants: []*multisig.Participant{{Signature: alice, Weight: 1}, {Signature: bob, Weight: 1}},
+	}
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/contracts?range": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("contracts:1"), []byte("contracts:2")},
+					[]weave.Persistent{first, second}),
+				// Range query is continued from the last returned key.
+				"33323A": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("contracts:2")},
+					[]weave.Persistent{second}),
+			},
+		},
+	}
+	h := MultisigParticipantHandler{Index: NewMultisigParticipantIndex(bns, time.Minute)}
+
+	r, _ := http.NewRequest("GET", "/multisig/participant/"+bob.String(), nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	bnsapitest.AssertAPIResponse(t, w, []util.KeyValue{
+		{Key: []byte("contracts:2"), Value: second},
+	})
+}
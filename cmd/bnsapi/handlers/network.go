@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/crypto/bech32"
+	"github.com/iov-one/weave/errors"
+	"net/http"
+	"strings"
+)
+
+// Network describes a blockchain network that bnsapi instance is serving.
+type Network struct {
+	Name string
+	// HRP is the human readable part of the bech32 encoded addresses that
+	// belong to this network.
+	HRP string
+}
+
+var (
+	Mainnet = Network{Name: "mainnet", HRP: "iov"}
+	Testnet = Network{Name: "testnet", HRP: "tiov"}
+)
+
+// NetworkByName returns the network with given name. Empty name returns no
+// network, meaning that addresses of any network are accepted.
+func NetworkByName(name string) (*Network, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case Mainnet.Name:
+		return &Mainnet, nil
+	case Testnet.Name:
+		return &Testnet, nil
+	default:
+		return nil, errors.Wrapf(errors.ErrInput, "unknown network %q", name)
+	}
+}
+
+type networkKey struct{}
+
+// networkFrom returns the network that the request is served for or nil if
+// no network is configured.
+func networkFrom(ctx context.Context) *Network {
+	n, _ := ctx.Value(networkKey{}).(*Network)
+	return n
+}
+
+// requestAddress parses an address provided with the request. If a network
+// is configured, bech32 addresses of other networks are rejected.
+func requestAddress(r *http.Request, rawAddr string) (weave.Address, error) {
+	addr, err := WeaveAddressFromQuery(rawAddr)
+	if err != nil {
+		return nil, err
+	}
+	network := networkFrom(r.Context())
+	if network == nil {
+		return addr, nil
+	}
+	if strings.HasPrefix(rawAddr, "iov") || strings.HasPrefix(rawAddr, "tiov") {
+		rawAddr = "bech32:" + rawAddr
+	}
+	if !strings.HasPrefix(rawAddr, "bech32:") {
+		return addr, nil
+	}
+	if hrp, _, err := bech32.Decode(rawAddr[len("bech32:"):]); err == nil && hrp != network.HRP {
+		return nil, errors.Wrapf(errors.ErrInput, "address does not belong to the %s network", network.Name)
+	}
+	return addr, nil
+}
+
+// AddressFormat is a middleware that serves requests for given network,
+// which can be nil. All weave.Address values of a JSON response are
+// rendered as bech32 strings of the network, if requested with the
+// address_format=bech32 query parameter.
+func AddressFormat(network *Network, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(context.WithValue(r.Context(), networkKey{}, network))
+		switch format := r.URL.Query().Get("address_format"); format {
+		case "", "hex":
+			next.ServeHTTP(w, r)
+		case "bech32":
+			if network == nil {
+				JSONErr(w, http.StatusBadRequest, "Network is not configured. Bech32 address format is not available.")
+				return
+			}
+			rd, r := withRenderer(r)
+			rd.hrp = network.HRP
+			next.ServeHTTP(newRenderWriter(w, rd), r)
+		default:
+			JSONErr(w, http.StatusBadRequest, fmt.Sprintf("unknown address format %q", format))
+		}
+	})
+}
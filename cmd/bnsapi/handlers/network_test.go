@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestAddressNetwork(t *testing.T) {
+	addr := weave.NewCondition("escrow", "seq", EncodeSequence(1)).Address()
+	forms := NewAddressForms(addr)
+
+	cases := map[string]struct {
+		network *Network
+		address string
+		wantErr bool
+	}{
+		"mainnet address on mainnet":    {network: &Mainnet, address: forms.Bech32Mainnet},
+		"testnet address on mainnet":    {network: &Mainnet, address: forms.Bech32Testnet, wantErr: true},
+		"prefixed testnet on mainnet":   {network: &Mainnet, address: "bech32:" + forms.Bech32Testnet, wantErr: true},
+		"hex address on mainnet":        {network: &Mainnet, address: forms.Hex},
+		"testnet address on no network": {address: forms.Bech32Testnet},
+	}
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			var err error
+			h := AddressFormat(tc.network, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err = requestAddress(r, tc.address)
+			}))
+			r, _ := http.NewRequest("GET", "/", nil)
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if hasErr := err != nil; hasErr != tc.wantErr {
+				t.Fatalf("want error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestAddressFormat(t *testing.T) {
+	owner := weave.NewCondition("escrow", "seq", EncodeSequence(1)).Address()
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		JSONResp(w, http.StatusOK, MultipleObjectsResponse{
+			Objects: []util.KeyValue{
+				{
+					Key:   []byte("foo*bar"),
+					Value: &account.Account{Name: "foo", Domain: "bar", Owner: owner},
+				},
+			},
+		})
+	})
+	// A middleware that wraps the response writer must not disable the
+	// rendering.
+	rendered := RenderResponses(h)
+	h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rendered.ServeHTTP(struct{ http.ResponseWriter }{w}, r)
+	})
+
+	r, _ := http.NewRequest("GET", "/?address_format=bech32", nil)
+	w := httptest.NewRecorder()
+	AddressFormat(nil, h).ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("bech32 format without a network must fail, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	AddressFormat(&Testnet, h).ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+
+	var payload struct {
+		Objects []struct {
+			Key   string
+			Value struct {
+				Name  string
+				Owner string
+			}
+		}
+	}
+	if err := json.NewDecoder(w.Body).Decode(&payload); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if len(payload.Objects) != 1 {
+		t.Fatalf("unexpected response: %+v", payload)
+	}
+	got := payload.Objects[0]
+	if want := NewAddressForms(owner).Bech32Testnet; got.Value.Owner != want {
+		t.Errorf("want %q owner, got %q", want, got.Value.Owner)
+	}
+	if got.Value.Name != "foo" || got.Key != "666f6f2a626172" {
+		t.Errorf("unexpected object: %+v", got)
+	}
+}
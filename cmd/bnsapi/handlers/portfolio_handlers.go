@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"context"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/termdeposit"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/coin"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/escrow"
+	"github.com/iov-one/weave/x/sigs"
+	"log"
+	"net/http"
+	"sync"
+)
+
+type PortfolioHandler struct {
+	Bns      client.BnsClient
+	Multisig MultisigParticipants
+}
+
+type portfolio struct {
+	Address weave.Address `json:"address"`
+	Balance *cash.Set     `json:"balance"`
+	// Nonce is the sequence of the next transaction signed by the
+	// address. It is zero if the address never signed a transaction.
+	Nonce              int64              `json:"nonce"`
+	EscrowsSource      []util.KeyValue    `json:"escrows_source"`
+	EscrowsDestination []util.KeyValue    `json:"escrows_destination"`
+	Deposits           []portfolioDeposit `json:"deposits"`
+	MultisigContracts  []util.KeyValue    `json:"multisig_contracts"`
+	Domains            []util.KeyValue    `json:"domains"`
+	Accounts           []util.KeyValue    `json:"accounts"`
+	Usernames          []util.KeyValue    `json:"usernames"`
+}
+
+type portfolioDeposit struct {
+	util.KeyValue
+	// Payout is the deposit amount together with the interest.
+	Payout coin.Coin `json:"payout"`
+}
+
+// PortfolioHandler godoc
+// @Summary Returns all holdings of an address.
+// @Description Holdings are the balance, the nonce, escrows that the address is the source or destination of,
+// @Description term deposits with their projected payout, multisig contracts the address participates in,
+// @Description owned domains, accounts and usernames. Each collection is limited to its first 1000 items.
+// @Tags IOV token
+// @Param address path string true "Address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)"
+// @Success 200 {object} handlers.portfolio
+// @Failure 400
+// @Failure 500
+// @Failure 503
+// @Router /portfolio/{address} [get]
+func (h *PortfolioHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	addr, err := requestAddress(r, LastChunk(r.URL.Path))
+	if err != nil || len(addr) == 0 {
+		JSONErr(w, http.StatusBadRequest, "address must be a valid address value")
+		return
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	p := portfolio{Address: addr}
+	var deposits []util.KeyValue
+	queries := []func() error{
+		func() (err error) {
+			p.Balance, err = walletBalance(ctx, h.Bns, addr)
+			return errors.Wrap(err, "wallet")
+		},
+		func() error {
+			var u sigs.UserData
+			switch err := client.ABCIKeyQuery(ctx, h.Bns, "/auth", addr, &models.KeyModel{Model: &u}); {
+			case err == nil:
+				p.Nonce = u.Sequence
+				return nil
+			case errors.ErrNotFound.Is(err):
+				return nil
+			default:
+				return errors.Wrap(err, "auth")
+			}
+		},
+		func() (err error) {
+			it := client.ABCIIndexRangeQuery(ctx, h.Bns, "/escrows/source", addr)
+			p.EscrowsSource, err = collectObjects(it, func() orm.Model { return &escrow.Escrow{} })
+			return errors.Wrap(err, "escrows source")
+		},
+		func() (err error) {
+			it := client.ABCIIndexRangeQuery(ctx, h.Bns, "/escrows/destination", addr)
+			p.EscrowsDestination, err = collectObjects(it, func() orm.Model { return &escrow.Escrow{} })
+			return errors.Wrap(err, "escrows destination")
+		},
+		func() (err error) {
+			it := client.ABCIIndexRangeQuery(ctx, h.Bns, "/deposits/depositor", addr)
+			deposits, err = collectObjects(it, func() orm.Model { return &termdeposit.Deposit{} })
+			return errors.Wrap(err, "deposits")
+		},
+		func() (err error) {
+			p.MultisigContracts, err = h.Multisig.Contracts(ctx, addr)
+			return errors.Wrap(err, "multisig contracts")
+		},
+		func() (err error) {
+			it := client.ABCIIndexRangeQuery(ctx, h.Bns, "/domains/admin", addr)
+			p.Domains, err = collectObjects(it, func() orm.Model { return &account.Domain{} })
+			return errors.Wrap(err, "domains")
+		},
+		func() (err error) {
+			it := client.ABCIIndexRangeQuery(ctx, h.Bns, "/accounts/owner", addr)
+			p.Accounts, err = collectObjects(it, func() orm.Model { return &account.Account{} })
+			return errors.Wrap(err, "accounts")
+		},
+		func() (err error) {
+			it := client.ABCIKeyQueryIter(ctx, h.Bns, "/usernames/owner", addr)
+			p.Usernames, err = collectObjects(it, func() orm.Model { return &username.Token{} })
+			if errors.ErrNotFound.Is(err) {
+				p.Usernames, err = []util.KeyValue{}, nil
+			}
+			return errors.Wrap(err, "usernames")
+		},
+	}
+
+	var (
+		wg       sync.WaitGroup
+		failOnce sync.Once
+		failErr  error
+	)
+	for _, query := range queries {
+		wg.Add(1)
+		go func(query func() error) {
+			defer wg.Done()
+			if err := query(); err != nil {
+				// Only the first error is relevant. Other
+				// queries are cancelled.
+				failOnce.Do(func() {
+					failErr = err
+					cancel()
+				})
+			}
+		}(query)
+	}
+	wg.Wait()
+	switch {
+	case failErr == nil:
+	case errors.ErrState.Is(failErr):
+		JSONErr(w, http.StatusServiceUnavailable, "index is not built yet")
+		return
+	default:
+		log.Printf("portfolio ABCI query: %s", failErr)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	p.Deposits = make([]portfolioDeposit, 0, len(deposits))
+	for _, kv := range deposits {
+		d := kv.Value.(*termdeposit.Deposit)
+		_, payout, err := depositPayout(d.Amount, d.Rate)
+		if err != nil {
+			log.Printf("portfolio deposit payout: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+		p.Deposits = append(p.Deposits, portfolioDeposit{KeyValue: kv, Payout: payout})
+	}
+
+	JSONResp(w, http.StatusOK, p)
+}
+
+// collectObjects returns at most PaginationMaxItems entities returned by the
+// iterator. Each entity is decoded into a model returned by newModel.
+func collectObjects(it client.ABCIIterator, newModel func() orm.Model) ([]util.KeyValue, error) {
+	objects := make([]util.KeyValue, 0, util.PaginationMaxItems)
+	for len(objects) < util.PaginationMaxItems {
+		m := newModel()
+		switch key, err := it.Next(m); {
+		case err == nil:
+			objects = append(objects, util.KeyValue{
+				Key:   key,
+				Value: m,
+			})
+		case errors.ErrIteratorDone.Is(err):
+			return objects, nil
+		default:
+			return nil, err
+		}
+	}
+	return objects, nil
+}
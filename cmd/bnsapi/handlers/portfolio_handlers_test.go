@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/termdeposit"
+	"github.com/iov-one/weave/coin"
+	"github.com/iov-one/weave/weavetest"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/sigs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPortfolioHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+
+	addr := weavetest.NewCondition().Address()
+	indexRange := func(offset []byte) string {
+		return hexKey([]byte(fmt.Sprintf("%x:%x:%x", []byte(addr), offset, NextKeyValue(addr))))
+	}
+	empty := bnsapitest.NewAbciQueryResponse(t, nil, nil)
+
+	// More deposits than a single range query returns.
+	var depositKeys [][]byte
+	var deposits []weave.Persistent
+	for i := 0; i < 60; i++ {
+		depositKeys = append(depositKeys, append([]byte("deposit:"), EncodeSequence(uint64(i+1))...))
+		deposits = append(deposits, &termdeposit.Deposit{
+			Amount: coin.NewCoin(10, 0, "IOV"),
+			Rate:   weave.Fraction{Numerator: 1, Denominator: 2},
+		})
+	}
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/wallets": {
+				hexKey(addr): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{addr},
+					[]weave.Persistent{&cash.Set{Coins: []*coin.Coin{coin.NewCoinp(4, 0, "IOV")}}}),
+			},
+			"/auth": {
+				hexKey(addr): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{addr},
+					[]weave.Persistent{&sigs.UserData{Sequence: 3}}),
+			},
+			"/deposits/depositor?range": {
+				indexRange(nil):                bnsapitest.NewAbciQueryResponse(t, depositKeys[:50], deposits[:50]),
+				indexRange(EncodeSequence(50)): bnsapitest.NewAbciQueryResponse(t, depositKeys[49:], deposits[49:]),
+				indexRange(EncodeSequence(60)): bnsapitest.NewAbciQueryResponse(t, depositKeys[59:], deposits[59:]),
+			},
+			"/escrows/source?range":      {indexRange(nil): empty},
+			"/escrows/destination?range": {indexRange(nil): empty},
+			"/domains/admin?range":       {indexRange(nil): empty},
+			"/accounts/owner?range":      {indexRange(nil): empty},
+			"/usernames/owner":           {hexKey(addr): empty},
+			// Multisig participant index reads all contracts.
+			"/contracts?range": {"": empty, "3A": empty},
+		},
+	}
+	h := PortfolioHandler{Bns: bns, Multisig: NewMultisigParticipantIndex(bns, time.Minute)}
+
+	r, _ := http.NewRequest("GET", "/portfolio/"+addr.String(), nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var p struct {
+		Balance  cash.Set
+		Nonce    int64
+		Deposits []struct {
+			Payout coin.Coin
+		}
+		Usernames []json.RawMessage
+	}
+	if err := json.NewDecoder(w.Body).Decode(&p); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if p.Nonce != 3 {
+		t.Fatalf("want nonce 3, got %d", p.Nonce)
+	}
+	if len(p.Balance.Coins) != 1 || !p.Balance.Coins[0].Equals(coin.NewCoin(4, 0, "IOV")) {
+		t.Fatalf("unexpected balance: %v", p.Balance.Coins)
+	}
+	if len(p.Deposits) != len(deposits) {
+		t.Fatalf("want %d deposits, got %d", len(deposits), len(p.Deposits))
+	}
+	for _, d := range p.Deposits {
+		if !d.Payout.Equals(coin.NewCoin(15, 0, "IOV")) {
+			t.Fatalf("unexpected deposit payout: %v", d.Payout)
+		}
+	}
+	if p.Usernames == nil || len(p.Usernames) != 0 {
+		t.Fatalf("want an empty usernames list, got %v", p.Usernames)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave/cmd/bnsd/x/preregistration"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/migration"
+	"log"
+	"net/http"
+	"sort"
+)
+
+type PreregistrationRecordsHandler struct {
+	Bns client.BnsClient
+}
+
+// PreregistrationRecordsHandler godoc
+// @Summary Returns a list of bnsd/x/preregistration Record entities, being the reserved domains.
+// @Description If domain parameter is provided return the record of that domain only.
+// @Tags Starname
+// @Param domain query string false "Domain name, ex: neuma"
+// @Param offset query string false "Pagination offset, being the domain name to start from"
+// @Success 200 {object} handlers.MultipleObjectsResponse
+// @Failure 404
+// @Failure 500
+// @Router /preregistration/records [get]
+func (h *PreregistrationRecordsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if !AtMostOne(q, "domain", "offset") {
+		JSONErr(w, http.StatusBadRequest, "At most one filter can be used at a time.")
+		return
+	}
+
+	if domain := q.Get("domain"); domain != "" {
+		var rec preregistration.Record
+		res := models.KeyModel{
+			Model: &rec,
+		}
+		switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/preregistrationrecords", []byte(domain), &res); {
+		case err == nil:
+			JSONResp(w, http.StatusOK, res)
+		case errors.ErrNotFound.Is(err):
+			JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		default:
+			log.Printf("preregistration record ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		}
+		return
+	}
+
+	it := client.ABCIRangeQuery(r.Context(), h.Bns, "/preregistrationrecords", fmt.Sprintf("%x:", q.Get("offset")))
+	objects := make([]util.KeyValue, 0, util.PaginationMaxItems)
+fetchRecords:
+	for {
+		var rec preregistration.Record
+		switch key, err := it.Next(&rec); {
+		case err == nil:
+			objects = append(objects, util.KeyValue{
+				Key:   key,
+				Value: &rec,
+			})
+			if len(objects) == util.PaginationMaxItems {
+				break fetchRecords
+			}
+		case errors.ErrIteratorDone.Is(err):
+			break fetchRecords
+		default:
+			log.Printf("preregistration records ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	JSONResp(w, http.StatusOK, MultipleObjectsResponse{
+		Objects: objects,
+	})
+}
+
+type MigrationSchemasHandler struct {
+	Bns client.BnsClient
+}
+
+type packageSchema struct {
+	Pkg     string `json:"pkg"`
+	Version uint32 `json:"version"`
+}
+
+// MigrationSchemasHandler godoc
+// @Summary Returns the current schema version of every package.
+// @Description Packages are ordered by name.
+// @Tags Status
+// @Success 200 {array} handlers.packageSchema
+// @Failure 500
+// @Router /migration/schemas [get]
+func (h *MigrationSchemasHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Each schema version of a package is a separate entity. The current
+	// version is the highest one.
+	versions := make(map[string]uint32)
+	it := client.ABCIFullRangeQuery(r.Context(), h.Bns, "/schemas", "")
+fetchSchemas:
+	for {
+		var s migration.Schema
+		switch _, err := it.Next(&s); {
+		case err == nil:
+			if s.Version > versions[s.Pkg] {
+				versions[s.Pkg] = s.Version
+			}
+		case errors.ErrIteratorDone.Is(err):
+			break fetchSchemas
+		default:
+			log.Printf("migration schemas ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	schemas := make([]packageSchema, 0, len(versions))
+	for pkg, ver := range versions {
+		schemas = append(schemas, packageSchema{Pkg: pkg, Version: ver})
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Pkg < schemas[j].Pkg })
+
+	JSONResp(w, http.StatusOK, schemas)
+}
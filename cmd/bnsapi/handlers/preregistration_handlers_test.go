@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/preregistration"
+	"github.com/iov-one/weave/migration"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPreregistrationRecordsHandler(t *testing.T) {
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/preregistrationrecords?range": {
+				"3A": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("records:neuma")},
+					[]weave.Persistent{&preregistration.Record{Domain: "neuma"}}),
+			},
+		},
+	}
+	h := PreregistrationRecordsHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/preregistration/records", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	bnsapitest.AssertAPIResponse(t, w, []util.KeyValue{
+		{
+			Key:   []byte("records:neuma"),
+			Value: &preregistration.Record{Domain: "neuma"},
+		},
+	})
+}
+
+func TestMigrationSchemasHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/schemas?range": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("schema:cash1"), []byte("schema:gov1"), []byte("schema:gov2")},
+					[]weave.Persistent{
+						&migration.Schema{Pkg: "cash", Version: 1},
+						&migration.Schema{Pkg: "gov", Version: 1},
+						&migration.Schema{Pkg: "gov", Version: 2},
+					}),
+				// Range query is continued from the last returned key.
+				hexKey([]byte(fmt.Sprintf("%x:", "gov2"))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("schema:gov2")},
+					[]weave.Persistent{&migration.Schema{Pkg: "gov", Version: 2}}),
+			},
+		},
+	}
+	h := MigrationSchemasHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/migration/schemas", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var schemas []packageSchema
+	if err := json.NewDecoder(w.Body).Decode(&schemas); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	want := []packageSchema{{Pkg: "cash", Version: 1}, {Pkg: "gov", Version: 2}}
+	if !reflect.DeepEqual(want, schemas) {
+		t.Fatalf("want %+v, got %+v", want, schemas)
+	}
+}
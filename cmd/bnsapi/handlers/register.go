@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/indexer"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Options configures the BNS API mounted by Register.
+type Options struct {
+	// Prefix is the path that all endpoints are mounted under, ie /bns.
+	// Empty prefix mounts the endpoints at the root.
+	Prefix string
+	// Network is the network that the API is serving. When nil, addresses
+	// of any network are accepted and bech32 rendering is not available.
+	Network *Network
+	// Confs is the registry of gconf configurations that can be browsed.
+	// When nil, the bnsd configurations are used.
+	Confs *GconfRegistry
+	// Middleware, if set, wraps all the endpoints. Request paths seen by
+	// the middleware do not contain the prefix.
+	Middleware func(http.Handler) http.Handler
+	// Indexer, if set, serves the lookups that bnsd does not index. The
+	// indexer must maintain the indexer.DefaultIndexes and
+	// indexer.DefaultJournals and is synchronized by the caller. When nil,
+	// the indexes are periodically rebuilt in memory instead and history
+	// endpoints are not available.
+	Indexer *indexer.Indexer
+	// IndexTTL is how long the indexes built in memory are served before
+	// being rebuilt. Zero means five minutes.
+	IndexTTL time.Duration
+}
+
+// defaultIndexTTL is the IndexTTL used when not configured.
+const defaultIndexTTL = 5 * time.Minute
+
+// Register mounts all BNS API endpoints on given mux, under the path prefix
+// configured by the options.
+func Register(mux *http.ServeMux, bns client.BnsClient, opts Options) {
+	confs := opts.Confs
+	if confs == nil {
+		confs = NewGconfRegistry()
+	}
+	indexTTL := opts.IndexTTL
+	if indexTTL == 0 {
+		indexTTL = defaultIndexTTL
+	}
+	prefix := strings.TrimRight(opts.Prefix, "/")
+
+	api := http.NewServeMux()
+	api.Handle("/info", &InfoHandler{})
+	api.Handle("/blocks/", &BlocksHandler{Bns: bns})
+	api.Handle("/account/domains", &DomainsHandler{Bns: bns})
+	api.Handle("/account/accounts", &AccountsHandler{Bns: bns})
+	api.Handle("/account/resolve/", &AccountResolveHandler{Bns: bns, Indexer: opts.Indexer})
+	api.Handle("/nonce/address/", &NonceAddressHandler{Bns: bns})
+	api.Handle("/nonce/pubkey/", &NoncePubKeyHandler{Bns: bns})
+	api.Handle("/address/convert/", &AddressConvertHandler{})
+	api.Handle("/username/owner/", &OwnerHandler{Bns: bns})
+	api.Handle("/username/resolve/", &ResolveHandler{Bns: bns})
+	var usernameTargets UsernameTargets = NewUsernameTargetIndex(bns, indexTTL)
+	if opts.Indexer != nil {
+		usernameTargets = &IndexedUsernameTargets{Bns: bns, Indexer: opts.Indexer}
+	}
+	api.Handle("/username/reverse", &UsernameReverseHandler{Index: usernameTargets})
+	api.Handle("/username/usernames", &UsernamesHandler{Bns: bns})
+	api.Handle("/username/", &UsernameAccountHandler{Bns: bns})
+	api.Handle("/cash/balances", &CashBalanceHandler{Bns: bns})
+	wallets := NewWalletIndex(bns, indexTTL)
+	api.Handle("/cash/supply", &CashSupplyHandler{Wallets: wallets})
+	api.Handle("/cash/holders", &CashHoldersHandler{Wallets: wallets})
+	api.Handle("/currency/tokens", &CurrencyTokensHandler{Bns: bns})
+	api.Handle("/termdeposit/contracts", &ContractsHandler{Bns: bns})
+	api.Handle("/termdeposit/contracts/", &ContractSummaryHandler{Bns: bns})
+	api.Handle("/termdeposit/depositors/", &DepositorSummaryHandler{Bns: bns})
+	api.Handle("/termdeposit/deposits", &DepositsHandler{Bns: bns})
+	api.Handle("/termdeposit/deposits/", &DepositDetailHandler{Bns: bns})
+	api.Handle("/termdeposit/quote", &DepositQuoteHandler{Bns: bns})
+	api.Handle("/multisig/contracts", &MultisigContractsHandler{Bns: bns})
+	api.Handle("/multisig/contracts/", &MultisigContractDetailHandler{Bns: bns})
+	var participants MultisigParticipants = NewMultisigParticipantIndex(bns, indexTTL)
+	if opts.Indexer != nil {
+		participants = &IndexedMultisigParticipants{Bns: bns, Indexer: opts.Indexer}
+	}
+	api.Handle("/multisig/participant/", &MultisigParticipantHandler{Index: participants})
+	api.Handle("/escrow/escrows", &EscrowEscrowsHandler{Bns: bns})
+	api.Handle("/escrow/escrows/", &EscrowDetailHandler{Bns: bns})
+	api.Handle("/gov/proposals", &GovProposalsHandler{Bns: bns})
+	api.Handle("/gov/proposals/", &GovProposalTallyHandler{Bns: bns})
+	api.Handle("/gov/votes", &GovVotesHandler{Bns: bns})
+	api.Handle("/gov/electorates", &GovElectoratesHandler{Bns: bns})
+	api.Handle("/gov/electorates/", &GovElectorateDetailHandler{Bns: bns})
+	api.Handle("/gov/electorates/member/", &GovElectorateMemberHandler{Bns: bns})
+	api.Handle("/gov/rules", &GovRulesHandler{Bns: bns})
+	api.Handle("/gov/rules/", &GovRuleDetailHandler{Bns: bns})
+	api.Handle("/gconf", &GconfSnapshotHandler{Bns: bns, Confs: confs})
+	api.Handle("/gconf/", &GconfHandler{Bns: bns, Confs: confs})
+	api.Handle("/msgfee/msgfees", &MsgFeeHandler{Bns: bns})
+	api.Handle("/preregistration/records", &PreregistrationRecordsHandler{Bns: bns})
+	api.Handle("/migration/schemas", &MigrationSchemasHandler{Bns: bns})
+	api.Handle("/tx/submit", &TxSubmitHandler{Bns: bns})
+	challenges := &AuthChallenges{}
+	api.Handle("/auth/challenge", &AuthChallengeHandler{Challenges: challenges})
+	api.Handle("/auth/verify", &AuthVerifyHandler{Bns: bns, Challenges: challenges})
+	api.Handle("/portfolio/", &PortfolioHandler{Bns: bns, Multisig: participants})
+	api.Handle("/export/", &ExportHandler{Bns: bns})
+	api.Handle("/", &DefaultHandler{Prefix: prefix})
+
+	h := RenderResponses(api)
+	if opts.Middleware != nil {
+		h = opts.Middleware(h)
+	}
+	h = AddressFormat(opts.Network, AmountFormat(bns, h))
+
+	if prefix == "" {
+		mux.Handle("/", h)
+		return
+	}
+	mux.Handle(prefix+"/", http.StripPrefix(prefix, h))
+}
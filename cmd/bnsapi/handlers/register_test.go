@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegister(t *testing.T) {
+	var seen []string
+	mux := http.NewServeMux()
+	Register(mux, &bnsapitest.BnsClientMock{}, Options{
+		Prefix: "/bns/",
+		Middleware: func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = append(seen, r.URL.Path)
+				next.ServeHTTP(w, r)
+			})
+		},
+	})
+
+	cases := map[string]struct {
+		path     string
+		wantCode int
+		wantLoc  string
+	}{
+		"endpoint under prefix":   {path: "/bns/info", wantCode: http.StatusOK},
+		"endpoint without prefix": {path: "/info", wantCode: http.StatusNotFound},
+		"redirect keeps prefix":   {path: "/bns/info/", wantCode: http.StatusPermanentRedirect, wantLoc: "/bns/info"},
+	}
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tc.path, nil)
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d response, got %d: %s", tc.wantCode, w.Code, w.Body)
+			}
+			if loc := w.Header().Get("Location"); loc != tc.wantLoc {
+				t.Fatalf("want %q location, got %q", tc.wantLoc, loc)
+			}
+		})
+	}
+
+	// Only requests under the prefix reach the middleware, with the
+	// prefix stripped.
+	if len(seen) != 2 {
+		t.Fatalf("unexpected paths seen by the middleware: %q", seen)
+	}
+	for _, path := range seen {
+		if strings.HasPrefix(path, "/bns") {
+			t.Fatalf("middleware path contains prefix: %q", path)
+		}
+	}
+}
@@ -0,0 +1,405 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/coin"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// renderer describes how the response content is rendered. It is carried
+// in the request context, so that it is configured by several middlewares
+// independently of how they wrap the response writer.
+type renderer struct {
+	// hrp when not empty, renders all addresses as bech32 strings with
+	// this human readable part.
+	hrp string
+	// decimal when set, renders all coins as decimal amounts together with
+	// the token information.
+	decimal bool
+	tokens  map[string]string
+}
+
+type rendererKey struct{}
+
+// withRenderer returns the renderer of the request, attaching a new one to
+// the returned request if the request has none yet.
+func withRenderer(r *http.Request) (*renderer, *http.Request) {
+	if rd, ok := r.Context().Value(rendererKey{}).(*renderer); ok {
+		return rd, r
+	}
+	rd := &renderer{}
+	return rd, r.WithContext(context.WithValue(r.Context(), rendererKey{}, rd))
+}
+
+// RenderResponses is a middleware that passes the renderer configured in the
+// request context to JSONResp. It must wrap the handlers directly, so that
+// the writer it provides is not hidden by writers of other middlewares.
+func RenderResponses(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rd, ok := r.Context().Value(rendererKey{}).(*renderer); ok {
+			w = newRenderWriter(w, rd)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// renderWriter is recognized by JSONResp, which renders the response content
+// with the writer renderer before serializing it.
+type renderWriter struct {
+	http.ResponseWriter
+	*renderer
+}
+
+func newRenderWriter(w http.ResponseWriter, rd *renderer) *renderWriter {
+	if rw, ok := w.(*renderWriter); ok && rw.renderer == rd {
+		return rw
+	}
+	return &renderWriter{ResponseWriter: w, renderer: rd}
+}
+
+// Flush implements http.Flusher if the wrapped writer supports it, so that
+// streamed responses are not buffered by the renderer.
+func (rw *renderWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// decimalCoin is the decimal representation of a coin.Coin.
+type decimalCoin struct {
+	Amount string `json:"amount"`
+	Ticker string `json:"ticker"`
+	// Display is the human readable format, for example "12.5 IOV".
+	Display string `json:"display"`
+	Name    string `json:"name,omitempty"`
+	// Precision is the number of fractional digits that the coin supports.
+	Precision int `json:"precision"`
+}
+
+// coinPrecision is the number of fractional digits of every weave coin. The
+// weave coin fractional unit is 10^9 (coin.FracUnit) for all tickers and
+// currency.TokenInfo has no precision field, so it is not read per token.
+const coinPrecision = 9
+
+var (
+	addressType   = reflect.TypeOf(weave.Address(nil))
+	coinType      = reflect.TypeOf(coin.Coin{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textType      = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// render returns a JSON serializable copy of given value, with all values
+// that the renderer is configured for replaced by their alternative
+// representation. Structures are serialized the same way the encoding/json
+// package does it, including embedded structs, the omitempty and string
+// field options and values that implement json.Marshaler or
+// encoding.TextMarshaler.
+func (rd *renderer) render(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	switch t := v.Type(); {
+	case t == addressType && rd.hrp != "":
+		if v.Len() == 0 {
+			return ""
+		}
+		s, err := v.Interface().(weave.Address).Bech32String(rd.hrp)
+		if err != nil {
+			return v.Interface()
+		}
+		return s
+	case t == coinType && rd.decimal:
+		c := v.Interface().(coin.Coin)
+		return decimalCoin{
+			Amount:    coin.Coin{Whole: c.Whole, Fractional: c.Fractional}.String(),
+			Ticker:    c.Ticker,
+			Display:   c.String(),
+			Name:      rd.tokens[c.Ticker],
+			Precision: coinPrecision,
+		}
+	case t.Implements(marshalerType) || t.Implements(textType):
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return rd.render(v.Elem())
+	case reflect.Struct:
+		if pt := reflect.PtrTo(v.Type()); (pt.Implements(marshalerType) || pt.Implements(textType)) && v.CanAddr() {
+			return v.Addr().Interface()
+		}
+		var obj orderedObject
+		rd.appendFields(&obj, v)
+		return obj
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+		fallthrough
+	case reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		items := make([]interface{}, v.Len())
+		for i := range items {
+			items[i] = rd.render(v.Index(i))
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() || v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		items := make(map[string]interface{}, v.Len())
+		for _, k := range v.MapKeys() {
+			items[k.String()] = rd.render(v.MapIndex(k))
+		}
+		return items
+	default:
+		return v.Interface()
+	}
+}
+
+func (rd *renderer) appendFields(obj *orderedObject, v reflect.Value) {
+fields:
+	for _, f := range structFields(v.Type()) {
+		fv := v
+		for _, i := range f.index {
+			if fv.Kind() == reflect.Ptr {
+				// Fields of a nil embedded struct are omitted.
+				if fv.IsNil() {
+					continue fields
+				}
+				fv = fv.Elem()
+			}
+			fv = fv.Field(i)
+		}
+		if hasTagOption(f.opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		if hasTagOption(f.opts, "string") {
+			if s, ok := quotedValue(fv); ok {
+				*obj = append(*obj, objectField{name: f.name, value: s})
+				continue
+			}
+		}
+		*obj = append(*obj, objectField{name: f.name, value: rd.render(fv)})
+	}
+}
+
+// structField is a field of a struct, possibly promoted from an embedded
+// struct, that encoding/json serializes.
+type structField struct {
+	name   string
+	opts   string
+	tagged bool
+	// index is the index sequence of the field, as used by
+	// reflect.Value.FieldByIndex.
+	index []int
+	// typ is set only while collecting fields, for embedded structs.
+	typ reflect.Type
+}
+
+var structFieldsCache sync.Map // map[reflect.Type][]structField
+
+// structFields returns the fields of a struct type that encoding/json
+// serializes, in the same order. Fields of embedded structs are promoted and
+// conflicting names are resolved using the Go visibility rules, the same way
+// encoding/json does it.
+func structFields(t reflect.Type) []structField {
+	if fields, ok := structFieldsCache.Load(t); ok {
+		return fields.([]structField)
+	}
+
+	var fields []structField
+	visited := make(map[reflect.Type]bool)
+	// Embedded structs are visited breadth first, so that fields are
+	// collected by their depth.
+	next := []structField{{typ: t}}
+	for len(next) > 0 {
+		current := next
+		next = nil
+		for _, embedded := range current {
+			if visited[embedded.typ] {
+				continue
+			}
+			visited[embedded.typ] = true
+
+			for i := 0; i < embedded.typ.NumField(); i++ {
+				sf := embedded.typ.Field(i)
+				ft := sf.Type
+				if ft.Name() == "" && ft.Kind() == reflect.Ptr {
+					ft = ft.Elem()
+				}
+				if sf.Anonymous {
+					// Unexported embedded structs still
+					// provide their exported fields.
+					if sf.PkgPath != "" && ft.Kind() != reflect.Struct {
+						continue
+					}
+				} else if sf.PkgPath != "" {
+					continue
+				}
+				tag := sf.Tag.Get("json")
+				if tag == "-" {
+					continue
+				}
+				name, opts := tag, ""
+				if i := strings.Index(tag, ","); i >= 0 {
+					name, opts = tag[:i], tag[i+1:]
+				}
+
+				index := make([]int, len(embedded.index)+1)
+				copy(index, embedded.index)
+				index[len(embedded.index)] = i
+
+				if name == "" && sf.Anonymous && ft.Kind() == reflect.Struct {
+					next = append(next, structField{index: index, typ: ft})
+					continue
+				}
+				f := structField{name: name, opts: opts, tagged: name != "", index: index}
+				if name == "" {
+					f.name = sf.Name
+				}
+				fields = append(fields, f)
+			}
+		}
+	}
+
+	// Of all fields with the same name, the least nested one is used. If
+	// there are several at the same depth, a tagged one wins. Otherwise
+	// all are ignored.
+	sort.Slice(fields, func(i, j int) bool {
+		a, b := fields[i], fields[j]
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		if len(a.index) != len(b.index) {
+			return len(a.index) < len(b.index)
+		}
+		if a.tagged != b.tagged {
+			return a.tagged
+		}
+		return lessIndex(a.index, b.index)
+	})
+	dominant := fields[:0]
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if j-i == 1 || len(fields[i].index) != len(fields[i+1].index) || fields[i].tagged != fields[i+1].tagged {
+			dominant = append(dominant, fields[i])
+		}
+		i = j
+	}
+	fields = dominant
+	sort.Slice(fields, func(i, j int) bool {
+		return lessIndex(fields[i].index, fields[j].index)
+	})
+
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func lessIndex(a, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+func hasTagOption(opts, name string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == name {
+			return true
+		}
+	}
+	return false
+}
+
+// quotedValue returns the JSON string that encoding/json serializes a field
+// with the string option to. Only fields of scalar types are quoted, the
+// option is ignored for other types.
+func quotedValue(v reflect.Value) (interface{}, bool) {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, true
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64,
+		reflect.String:
+		b, err := json.Marshal(v.Interface())
+		if err != nil {
+			return nil, false
+		}
+		return string(b), true
+	}
+	return nil, false
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// orderedObject is a JSON object that preserves the order of its fields.
+type orderedObject []objectField
+
+type objectField struct {
+	name  string
+	value interface{}
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(f.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestRenderStringOption(t *testing.T) {
+	height := int64(7)
+	content := struct {
+		Height  int64    `json:"height,string"`
+		Pointer *int64   `json:"pointer,string"`
+		Nil     *int64   `json:"nil,string"`
+		Empty   int64    `json:"empty,omitempty,string"`
+		Name    string   `json:"name,string"`
+		List    []string `json:"list,string"`
+	}{
+		Height:  height,
+		Pointer: &height,
+		Name:    "foo",
+		List:    []string{"a"},
+	}
+
+	want, err := json.Marshal(content)
+	if err != nil {
+		t.Fatalf("cannot serialize: %s", err)
+	}
+	var rd renderer
+	got, err := json.Marshal(rd.render(reflect.ValueOf(content)))
+	if err != nil {
+		t.Fatalf("cannot serialize rendered content: %s", err)
+	}
+	if string(got) != string(want) {
+		t.Fatalf("want %s, got %s", want, got)
+	}
+}
+
+func TestRenderMatchesEncodingJSON(t *testing.T) {
+	type inner struct {
+		ID   int    `json:"id"`
+		Name string `json:"name,omitempty"`
+	}
+	type unexported struct {
+		Visible string
+	}
+	type Embedded struct {
+		Name  string `json:"name"`
+		Extra string `json:"extra"`
+	}
+	type Left struct {
+		Dup  string
+		Left string
+	}
+	type Right struct {
+		Dup string
+	}
+	zero := 0
+	zeroPtr := &zero
+
+	cases := map[string]interface{}{
+		"embedded struct": struct {
+			inner
+			Title string `json:"title"`
+		}{inner: inner{ID: 1, Name: "a"}, Title: "t"},
+		"embedded pointer": struct {
+			*inner
+			Title string
+		}{inner: &inner{ID: 2}},
+		"nil embedded pointer": struct {
+			*inner
+			Title string
+		}{Title: "t"},
+		"embedded unexported type": struct {
+			unexported
+		}{unexported: unexported{Visible: "v"}},
+		"tagged embedded struct": struct {
+			inner `json:"inner"`
+		}{inner: inner{ID: 3}},
+		"shadowed embedded field": struct {
+			Embedded
+			Name string `json:"name"`
+		}{Embedded: Embedded{Name: "inner", Extra: "e"}, Name: "outer"},
+		"conflicting embedded fields": struct {
+			Left
+			Right
+		}{Left: Left{Dup: "a", Left: "l"}, Right: Right{Dup: "b"}},
+		"string option": struct {
+			Int     int      `json:"int,string"`
+			Uint    uint8    `json:"uint,string"`
+			Float   float64  `json:"float,string"`
+			Bool    bool     `json:"bool,string"`
+			Text    string   `json:"text,string"`
+			Pointer *int     `json:"pointer,string"`
+			Nested  inner    `json:"nested,string"`
+			List    []string `json:"list,string"`
+		}{Int: -3, Uint: 4, Float: 1.5, Bool: true, Text: `a"b`, Pointer: &zero},
+		"omitempty nested pointers": struct {
+			NilStruct   *inner   `json:"nil_struct,omitempty"`
+			EmptyStruct *inner   `json:"empty_struct,omitempty"`
+			ZeroInt     *int     `json:"zero_int,omitempty"`
+			NilPtrPtr   **int    `json:"nil_ptr_ptr,omitempty"`
+			PtrPtr      **int    `json:"ptr_ptr,omitempty"`
+			Struct      inner    `json:"struct,omitempty"`
+			List        []*inner `json:"list,omitempty"`
+			EmptyList   []*inner `json:"empty_list,omitempty"`
+		}{EmptyStruct: &inner{}, ZeroInt: &zero, PtrPtr: &zeroPtr, List: []*inner{nil, {ID: 4}}, EmptyList: []*inner{}},
+		"text marshaler": struct {
+			IP    net.IP      `json:"ip"`
+			Value textValue   `json:"value"`
+			Ptr   *textValue  `json:"ptr"`
+			List  []textValue `json:"list"`
+		}{IP: net.IPv4(127, 0, 0, 1), Value: textValue{n: 1}, Ptr: &textValue{n: 2}, List: []textValue{{n: 3}}},
+		"untagged and ignored fields": struct {
+			Plain   string
+			Ignored string `json:"-"`
+			Dash    string `json:"-,"`
+			private string
+		}{Plain: "p", Ignored: "i", Dash: "d", private: "x"},
+	}
+
+	for testName, content := range cases {
+		t.Run(testName, func(t *testing.T) {
+			want, err := json.Marshal(content)
+			if err != nil {
+				t.Fatalf("cannot serialize: %s", err)
+			}
+			var rd renderer
+			got, err := json.Marshal(rd.render(reflect.ValueOf(content)))
+			if err != nil {
+				t.Fatalf("cannot serialize rendered content: %s", err)
+			}
+			if string(got) != string(want) {
+				t.Fatalf("want %s, got %s", want, got)
+			}
+		})
+	}
+}
+
+// textValue is serialized by encoding/json using its text representation.
+type textValue struct {
+	n int
+}
+
+func (v textValue) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("text-%d", v.n)), nil
+}
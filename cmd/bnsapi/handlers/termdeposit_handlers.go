@@ -1,16 +1,23 @@
 package handlers
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
 	"github.com/iov-one/bns/cmd/bnsapi/util"
 	"github.com/iov-one/weave"
 	"github.com/iov-one/weave/cmd/bnsd/x/termdeposit"
+	"github.com/iov-one/weave/coin"
 	"github.com/iov-one/weave/errors"
 	"log"
+	"math/big"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type ContractsHandler struct {
@@ -106,13 +113,13 @@ func (h *DepositsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var it client.ABCIIterator
 	if d := q.Get("depositor"); len(d) > 0 {
-		rawAddr, err := weave.ParseAddress(d)
+		rawAddr, err := requestAddress(r, d)
 		if err != nil {
 			JSONErr(w, http.StatusBadRequest, "Depositor address must be a valid address value..")
 			return
 		}
 		end := NextKeyValue(rawAddr)
-		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/deposits/depositor", fmt.Sprintf("%s:%x:%x", d, offset, end))
+		it = client.ABCIRangeQuery(r.Context(), h.Bns, "/deposits/depositor", fmt.Sprintf("%s:%x:%x", rawAddr, offset, end))
 	} else if c := q.Get("contract_id"); len(c) > 0 {
 		n, err := strconv.ParseInt(c, 10, 64)
 		if err != nil {
@@ -160,3 +167,437 @@ fetchDeposits:
 		Objects: objects,
 	})
 }
+
+type DepositDetailHandler struct {
+	Bns client.BnsClient
+}
+
+type depositDetail struct {
+	Deposit  *termdeposit.Deposit         `json:"deposit"`
+	Contract *termdeposit.DepositContract `json:"contract"`
+	// Address is the address of the wallet that holds the deposited funds.
+	Address weave.Address `json:"address"`
+	// Interest is the deposit amount multiplied by the deposit rate.
+	Interest coin.Coin `json:"interest"`
+	// Payout is the deposit amount together with the interest.
+	Payout coin.Coin `json:"payout"`
+	// MaturityDate is the time after which the deposit can be released.
+	MaturityDate weave.UnixTime `json:"maturity_date"`
+	Releasable   bool           `json:"releasable"`
+}
+
+// DepositDetailHandler godoc
+// @Summary Returns a term deposit together with its expected payout and maturity date.
+// @Description A deposit is releasable once its contract expired, unless it was already released.
+// @Tags IOV token
+// @Param depositID path int true "Deposit ID"
+// @Success 200 {object} handlers.depositDetail
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /termdeposit/deposits/{depositID} [get]
+func (h *DepositDetailHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, err := ExtractNumericID(LastChunk(r.URL.Path))
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, "deposit ID must be an integer")
+		return
+	}
+
+	var d termdeposit.Deposit
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/deposits", id, &models.KeyModel{Model: &d}); {
+	case err == nil:
+	case errors.ErrNotFound.Is(err):
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	default:
+		log.Printf("termdeposit deposit ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	var c termdeposit.DepositContract
+	if err := client.ABCIKeyQuery(r.Context(), h.Bns, "/depositcontracts", d.DepositContractID, &models.KeyModel{Model: &c}); err != nil {
+		log.Printf("termdeposit contract ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	interest, payout, err := depositPayout(d.Amount, d.Rate)
+	if err != nil {
+		log.Printf("termdeposit deposit payout: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	JSONResp(w, http.StatusOK, depositDetail{
+		Deposit:      &d,
+		Contract:     &c,
+		Address:      weave.NewCondition("deposit", "seq", id).Address(),
+		Interest:     interest,
+		Payout:       payout,
+		MaturityDate: c.ValidUntil,
+		Releasable:   !d.Released && !c.ValidUntil.Time().After(time.Now()),
+	})
+}
+
+type DepositQuoteHandler struct {
+	Bns client.BnsClient
+}
+
+type depositQuote struct {
+	Contract *termdeposit.DepositContract `json:"contract"`
+	Amount   coin.Coin                    `json:"amount"`
+	// Rate is the rate a deposit made now would get.
+	Rate         weave.Fraction `json:"rate"`
+	Interest     coin.Coin      `json:"interest"`
+	Payout       coin.Coin      `json:"payout"`
+	MaturityDate weave.UnixTime `json:"maturity_date"`
+}
+
+// DepositQuoteHandler godoc
+// @Summary Simulates a term deposit made now and returns its rate and expected payout.
+// @Description The rate is computed using the bonuses of the termdeposit configuration, the same way bnsd does it.
+// @Tags IOV token
+// @Param contract_id query int true "Integer encoded Contract ID"
+// @Param amount query string true "Amount of tokens to deposit, ex: 10.5"
+// @Param ticker query string true "Ticker of the deposited token, ex: IOV. Deposit contracts do not restrict the token."
+// @Success 200 {object} handlers.depositQuote
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /termdeposit/quote [get]
+func (h *DepositQuoteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	cid, err := ExtractNumericID(q.Get("contract_id"))
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, "contract_id must be an integer contract sequence number.")
+		return
+	}
+	ticker := q.Get("ticker")
+	if !coin.IsCC(ticker) {
+		JSONErr(w, http.StatusBadRequest, "ticker must be a valid currency ticker, ex: IOV")
+		return
+	}
+	amount, err := coin.ParseHumanFormat(q.Get("amount") + " " + ticker)
+	if err != nil || !amount.IsPositive() {
+		JSONErr(w, http.StatusBadRequest, "amount must be a positive decimal number, ex: 10.5")
+		return
+	}
+
+	var c termdeposit.DepositContract
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/depositcontracts", cid, &models.KeyModel{Model: &c}); {
+	case err == nil:
+	case errors.ErrNotFound.Is(err):
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	default:
+		log.Printf("termdeposit contract ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	var conf termdeposit.Configuration
+	if err := client.ABCIKeyQuery(r.Context(), h.Bns, "/gconf", []byte("termdeposit"), &models.KeyModel{Model: &conf}); err != nil {
+		log.Printf("termdeposit gconf ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	rate, err := depositRate(&c, conf, time.Now())
+	switch {
+	case err == nil:
+	case errors.ErrExpired.Is(err), errors.ErrState.Is(err):
+		JSONErr(w, http.StatusBadRequest, fmt.Sprintf("contract does not accept deposits: %s", err))
+		return
+	default:
+		log.Printf("termdeposit deposit rate: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	interest, payout, err := depositPayout(amount, rate)
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, fmt.Sprintf("cannot compute payout: %s", err))
+		return
+	}
+
+	JSONResp(w, http.StatusOK, depositQuote{
+		Contract:     &c,
+		Amount:       amount,
+		Rate:         rate,
+		Interest:     interest,
+		Payout:       payout,
+		MaturityDate: c.ValidUntil,
+	})
+}
+
+// depositPayout returns the interest and the total payout of a deposit of
+// given amount and rate. Interest is rounded down to the smallest fractional
+// unit.
+func depositPayout(amount coin.Coin, rate weave.Fraction) (coin.Coin, coin.Coin, error) {
+	if rate.Denominator == 0 {
+		return coin.Coin{}, coin.Coin{}, errors.Wrap(errors.ErrInput, "zero rate denominator")
+	}
+	units := new(big.Int).Mul(big.NewInt(amount.Whole), big.NewInt(coin.FracUnit))
+	units.Add(units, big.NewInt(amount.Fractional))
+	units.Mul(units, big.NewInt(int64(rate.Numerator)))
+	units.Quo(units, big.NewInt(int64(rate.Denominator)))
+
+	whole, frac := new(big.Int).QuoRem(units, big.NewInt(coin.FracUnit), new(big.Int))
+	if !whole.IsInt64() {
+		return coin.Coin{}, coin.Coin{}, errors.Wrap(errors.ErrOverflow, "interest")
+	}
+	interest := coin.NewCoin(whole.Int64(), frac.Int64(), amount.Ticker)
+	payout, err := amount.Add(interest)
+	if err != nil {
+		return coin.Coin{}, coin.Coin{}, errors.Wrap(err, "payout")
+	}
+	return interest, payout, nil
+}
+
+// depositRate returns the rate of a deposit made within given contract at
+// given time. It mirrors the computation done by bnsd when a deposit is
+// created: the rate is interpolated between the bonuses of the lockin periods
+// directly shorter and longer than the deposit duration.
+func depositRate(contract *termdeposit.DepositContract, conf termdeposit.Configuration, now time.Time) (weave.Fraction, error) {
+	if now.After(contract.ValidUntil.Time()) {
+		return weave.Fraction{}, errors.Wrap(errors.ErrExpired, "contract out of date")
+	}
+	if now.Before(contract.ValidSince.Time()) {
+		return weave.Fraction{}, errors.Wrap(errors.ErrState, "contract not yet active")
+	}
+	if len(conf.Bonuses) == 0 {
+		return weave.Fraction{}, errors.Wrap(errors.ErrInput, "no deposit bonuses declared")
+	}
+
+	bonuses := make([]termdeposit.DepositBonus, len(conf.Bonuses))
+	copy(bonuses, conf.Bonuses)
+	sort.Slice(bonuses, func(i, j int) bool {
+		return bonuses[i].LockinPeriod < bonuses[j].LockinPeriod
+	})
+
+	duration := weave.UnixDuration(contract.ValidUntil - weave.AsUnixTime(now))
+
+	var (
+		lockPlus, lockMinus weave.UnixDuration
+		percPlus, percMinus weave.Fraction
+	)
+	for _, b := range bonuses {
+		if b.LockinPeriod < duration {
+			lockMinus = b.LockinPeriod
+			percMinus = b.Bonus
+		} else {
+			lockPlus = b.LockinPeriod
+			percPlus = b.Bonus
+			break
+		}
+	}
+	if lockPlus == 0 {
+		return bonuses[len(bonuses)-1].Bonus, nil
+	}
+	if lockMinus == 0 {
+		return bonuses[0].Bonus, nil
+	}
+
+	// r = (r+ - r-) / (T+ - T-) * (T - T-) + r-
+	rPlus := big.NewRat(int64(percPlus.Numerator), int64(percPlus.Denominator))
+	rMinus := big.NewRat(int64(percMinus.Numerator), int64(percMinus.Denominator))
+	rate := new(big.Rat).Sub(rPlus, rMinus)
+	rate.Quo(rate, big.NewRat(int64(lockPlus-lockMinus), 1))
+	rate.Mul(rate, big.NewRat(int64(duration-lockMinus), 1))
+	rate.Add(rate, rMinus)
+
+	return weave.Fraction{
+		Numerator:   uint32(rate.Num().Int64()),
+		Denominator: uint32(rate.Denom().Int64()),
+	}, nil
+}
+
+type ContractSummaryHandler struct {
+	Bns client.BnsClient
+}
+
+// ContractSummaryHandler godoc
+// @Summary Returns aggregated totals of all deposits made within a term deposit contract.
+// @Description Not found is returned if the contract does not exist.
+// @Tags IOV token
+// @Param contractID path int true "Contract ID"
+// @Success 200 {object} handlers.depositSummary
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /termdeposit/contracts/{contractID}/summary [get]
+func (h *ContractSummaryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rawID := PathAfter(r.URL.Path, "/contracts/")
+	if !strings.HasSuffix(rawID, "/summary") {
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+	cid, err := ExtractNumericID(strings.TrimSuffix(rawID, "/summary"))
+	if err != nil {
+		JSONErr(w, http.StatusBadRequest, "contract ID must be an integer")
+		return
+	}
+
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/depositcontracts", cid, &models.KeyModel{Model: &termdeposit.DepositContract{}}); {
+	case err == nil:
+	case errors.ErrNotFound.Is(err):
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	default:
+		log.Printf("termdeposit contract ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	it := client.ABCIIndexRangeQuery(r.Context(), h.Bns, "/deposits/contract", cid)
+	summary, err := summarizeDeposits(r.Context(), h.Bns, it)
+	if err != nil {
+		log.Printf("termdeposit contract summary: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	JSONResp(w, http.StatusOK, summary)
+}
+
+type DepositorSummaryHandler struct {
+	Bns client.BnsClient
+}
+
+// DepositorSummaryHandler godoc
+// @Summary Returns aggregated totals of all deposits made by a depositor.
+// @Description Not found is returned for an address that never made a deposit.
+// @Tags IOV token
+// @Param address path string true "Depositor address in bech32 (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)"
+// @Success 200 {object} handlers.depositSummary
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /termdeposit/depositors/{address}/summary [get]
+func (h *DepositorSummaryHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rawAddr := PathAfter(r.URL.Path, "/depositors/")
+	if !strings.HasSuffix(rawAddr, "/summary") {
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+	addr, err := requestAddress(r, strings.TrimSuffix(rawAddr, "/summary"))
+	if err != nil || len(addr) == 0 {
+		JSONErr(w, http.StatusBadRequest, "depositor address must be a valid address value")
+		return
+	}
+
+	it := client.ABCIIndexRangeQuery(r.Context(), h.Bns, "/deposits/depositor", addr)
+	summary, err := summarizeDeposits(r.Context(), h.Bns, it)
+	if err != nil {
+		log.Printf("termdeposit depositor summary: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	// An address is a depositor only once it made a deposit.
+	if summary.Count == 0 {
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+	JSONResp(w, http.StatusOK, summary)
+}
+
+type depositSummary struct {
+	Count         int `json:"count"`
+	LockedCount   int `json:"locked_count"`
+	ReleasedCount int `json:"released_count"`
+	// TotalLocked is the sum of all deposits that were not released yet.
+	TotalLocked coin.Coins `json:"total_locked"`
+	// TotalReleased is the sum of all released deposits.
+	TotalReleased coin.Coins `json:"total_released"`
+	// AverageRate is the deposit rate weighted by the deposit amount, as a
+	// decimal number.
+	AverageRate string `json:"average_rate"`
+	// Calendar is the expected payout of locked deposits grouped by the
+	// month of their maturity date.
+	Calendar []monthlyPayout `json:"calendar"`
+}
+
+type monthlyPayout struct {
+	// Month is in YYYY-MM format, in UTC.
+	Month  string     `json:"month"`
+	Count  int        `json:"count"`
+	Payout coin.Coins `json:"payout"`
+}
+
+// summarizeDeposits aggregates all deposits returned by the iterator.
+func summarizeDeposits(ctx context.Context, bns client.BnsClient, it client.ABCIIterator) (*depositSummary, error) {
+	summary := depositSummary{
+		TotalLocked:   coin.Coins{},
+		TotalReleased: coin.Coins{},
+		Calendar:      []monthlyPayout{},
+	}
+	contracts := make(map[string]*termdeposit.DepositContract)
+	months := make(map[string]*monthlyPayout)
+	weighted := new(big.Rat)
+	total := new(big.Int)
+
+	for {
+		var d termdeposit.Deposit
+		switch _, err := it.Next(&d); {
+		case err == nil:
+		case errors.ErrIteratorDone.Is(err):
+			for _, m := range months {
+				summary.Calendar = append(summary.Calendar, *m)
+			}
+			sort.Slice(summary.Calendar, func(i, j int) bool {
+				return summary.Calendar[i].Month < summary.Calendar[j].Month
+			})
+			summary.AverageRate = "0"
+			if total.Sign() > 0 {
+				summary.AverageRate = weighted.Quo(weighted, new(big.Rat).SetInt(total)).FloatString(9)
+			}
+			return &summary, nil
+		default:
+			return nil, errors.Wrap(err, "deposits")
+		}
+		summary.Count++
+		units := new(big.Int).Mul(big.NewInt(d.Amount.Whole), big.NewInt(coin.FracUnit))
+		units.Add(units, big.NewInt(d.Amount.Fractional))
+		total.Add(total, units)
+		if d.Rate.Denominator != 0 {
+			rate := big.NewRat(int64(d.Rate.Numerator), int64(d.Rate.Denominator))
+			weighted.Add(weighted, rate.Mul(rate, new(big.Rat).SetInt(units)))
+		}
+
+		var err error
+		if d.Released {
+			summary.ReleasedCount++
+			if summary.TotalReleased, err = summary.TotalReleased.Add(d.Amount); err != nil {
+				return nil, errors.Wrap(err, "total released")
+			}
+			continue
+		}
+		summary.LockedCount++
+		if summary.TotalLocked, err = summary.TotalLocked.Add(d.Amount); err != nil {
+			return nil, errors.Wrap(err, "total locked")
+		}
+
+		c, ok := contracts[string(d.DepositContractID)]
+		if !ok {
+			c = &termdeposit.DepositContract{}
+			if err := client.ABCIKeyQuery(ctx, bns, "/depositcontracts", d.DepositContractID, &models.KeyModel{Model: c}); err != nil {
+				return nil, errors.Wrap(err, "deposit contract")
+			}
+			contracts[string(d.DepositContractID)] = c
+		}
+		_, payout, err := depositPayout(d.Amount, d.Rate)
+		if err != nil {
+			return nil, errors.Wrap(err, "payout")
+		}
+		month := c.ValidUntil.Time().UTC().Format("2006-01")
+		m, ok := months[month]
+		if !ok {
+			m = &monthlyPayout{Month: month, Payout: coin.Coins{}}
+			months[month] = m
+		}
+		m.Count++
+		if m.Payout, err = m.Payout.Add(payout); err != nil {
+			return nil, errors.Wrap(err, "monthly payout")
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/termdeposit"
+	"github.com/iov-one/weave/coin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDepositRate(t *testing.T) {
+	const day = 24 * time.Hour
+	now := time.Now()
+	conf := termdeposit.Configuration{
+		Bonuses: []termdeposit.DepositBonus{
+			{LockinPeriod: weave.AsUnixDuration(20 * day), Bonus: weave.Fraction{Numerator: 2, Denominator: 10}},
+			{LockinPeriod: weave.AsUnixDuration(10 * day), Bonus: weave.Fraction{Numerator: 1, Denominator: 10}},
+		},
+	}
+	contract := func(until time.Duration) *termdeposit.DepositContract {
+		return &termdeposit.DepositContract{
+			ValidSince: weave.AsUnixTime(now.Add(-day)),
+			ValidUntil: weave.AsUnixTime(now).Add(until),
+		}
+	}
+
+	cases := map[string]struct {
+		contract *termdeposit.DepositContract
+		want     weave.Fraction
+		wantErr  bool
+	}{
+		"shorter than all lockin periods": {contract: contract(5 * day), want: weave.Fraction{Numerator: 1, Denominator: 10}},
+		"longer than all lockin periods":  {contract: contract(30 * day), want: weave.Fraction{Numerator: 2, Denominator: 10}},
+		"interpolated":                    {contract: contract(15 * day), want: weave.Fraction{Numerator: 3, Denominator: 20}},
+		"expired contract":                {contract: contract(-day), wantErr: true},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			got, err := depositRate(tc.contract, conf, now)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("cannot compute rate: %s", err)
+			}
+			if got != tc.want {
+				t.Fatalf("want %v rate, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDepositPayout(t *testing.T) {
+	interest, payout, err := depositPayout(coin.NewCoin(10, 500000000, "IOV"), weave.Fraction{Numerator: 1, Denominator: 3})
+	if err != nil {
+		t.Fatalf("cannot compute payout: %s", err)
+	}
+	if want := coin.NewCoin(3, 500000000, "IOV"); !interest.Equals(want) {
+		t.Fatalf("want %s interest, got %s", want, interest)
+	}
+	if want := coin.NewCoin(14, 0, "IOV"); !payout.Equals(want) {
+		t.Fatalf("want %s payout, got %s", want, payout)
+	}
+}
+
+func TestDepositDetailHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/deposits": {
+				hexKey(EncodeSequence(1)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(1)},
+					[]weave.Persistent{&termdeposit.Deposit{
+						DepositContractID: EncodeSequence(7),
+						Amount:            coin.NewCoin(100, 0, "IOV"),
+						Rate:              weave.Fraction{Numerator: 1, Denominator: 10},
+					}}),
+			},
+			"/depositcontracts": {
+				hexKey(EncodeSequence(7)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(7)},
+					[]weave.Persistent{&termdeposit.DepositContract{
+						ValidUntil: weave.AsUnixTime(time.Now().Add(-time.Hour)),
+					}}),
+			},
+		},
+	}
+	h := DepositDetailHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/termdeposit/deposits/1", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var detail depositDetail
+	if err := json.NewDecoder(w.Body).Decode(&detail); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if want := coin.NewCoin(110, 0, "IOV"); !detail.Payout.Equals(want) {
+		t.Fatalf("want %s payout, got %s", want, detail.Payout)
+	}
+	if !detail.Releasable {
+		t.Fatal("want deposit of an expired contract to be releasable")
+	}
+}
+
+func TestDepositQuoteHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+	const day = 24 * time.Hour
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/depositcontracts": {
+				hexKey(EncodeSequence(7)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(7)},
+					[]weave.Persistent{&termdeposit.DepositContract{
+						ValidSince: weave.AsUnixTime(time.Now().Add(-day)),
+						ValidUntil: weave.AsUnixTime(time.Now().Add(30 * day)),
+					}}),
+			},
+			"/gconf": {
+				hexKey([]byte("termdeposit")): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("_c:termdeposit")},
+					[]weave.Persistent{&termdeposit.Configuration{
+						Bonuses: []termdeposit.DepositBonus{
+							{LockinPeriod: weave.AsUnixDuration(day), Bonus: weave.Fraction{Numerator: 1, Denominator: 10}},
+						},
+					}}),
+			},
+		},
+	}
+	h := DepositQuoteHandler{Bns: bns}
+
+	cases := map[string]struct {
+		query      string
+		wantCode   int
+		wantPayout coin.Coin
+	}{
+		"ticker from the request": {
+			query:      "contract_id=7&amount=100&ticker=ETH",
+			wantCode:   http.StatusOK,
+			wantPayout: coin.NewCoin(110, 0, "ETH"),
+		},
+		"missing ticker": {
+			query:    "contract_id=7&amount=100",
+			wantCode: http.StatusBadRequest,
+		},
+		"invalid ticker": {
+			query:    "contract_id=7&amount=100&ticker=eth",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/termdeposit/quote?"+tc.query, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d response, got %d: %s", tc.wantCode, w.Code, w.Body)
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			var quote depositQuote
+			if err := json.NewDecoder(w.Body).Decode(&quote); err != nil {
+				t.Fatalf("cannot decode JSON response: %s", err)
+			}
+			if !quote.Payout.Equals(tc.wantPayout) {
+				t.Fatalf("want %s payout, got %s", tc.wantPayout, quote.Payout)
+			}
+		})
+	}
+}
+
+func TestContractSummaryHandler(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+
+	locked := &termdeposit.Deposit{
+		DepositContractID: EncodeSequence(7),
+		Amount:            coin.NewCoin(100, 0, "IOV"),
+		Rate:              weave.Fraction{Numerator: 1, Denominator: 10},
+	}
+	released := &termdeposit.Deposit{
+		DepositContractID: EncodeSequence(7),
+		Amount:            coin.NewCoin(50, 0, "IOV"),
+		Rate:              weave.Fraction{Numerator: 1, Denominator: 5},
+		Released:          true,
+	}
+	indexRange := func(offset []byte) string {
+		cid := EncodeSequence(7)
+		return hexKey([]byte(fmt.Sprintf("%x:%x:%x", cid, offset, NextKeyValue(cid))))
+	}
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/deposits/contract?range": {
+				indexRange(nil): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{append([]byte("deposit:"), EncodeSequence(1)...)},
+					[]weave.Persistent{locked}),
+				// Index range query is continued from the last
+				// returned deposit ID.
+				indexRange(EncodeSequence(1)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{
+						append([]byte("deposit:"), EncodeSequence(1)...),
+						append([]byte("deposit:"), EncodeSequence(2)...),
+					},
+					[]weave.Persistent{locked, released}),
+				indexRange(EncodeSequence(2)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{append([]byte("deposit:"), EncodeSequence(2)...)},
+					[]weave.Persistent{released}),
+			},
+			"/depositcontracts": {
+				hexKey(EncodeSequence(7)): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{EncodeSequence(7)},
+					[]weave.Persistent{&termdeposit.DepositContract{
+						ValidUntil: weave.AsUnixTime(time.Date(2030, time.March, 15, 0, 0, 0, 0, time.UTC)),
+					}}),
+				hexKey(EncodeSequence(8)): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+		},
+	}
+	h := ContractSummaryHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/termdeposit/contracts/8/summary", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("want 404 for a missing contract, got %d: %s", w.Code, w.Body)
+	}
+
+	r, _ = http.NewRequest("GET", "/termdeposit/contracts/7/summary", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var summary depositSummary
+	if err := json.NewDecoder(w.Body).Decode(&summary); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if summary.Count != 2 || summary.LockedCount != 1 || summary.ReleasedCount != 1 {
+		t.Fatalf("unexpected counts: %+v", summary)
+	}
+	if !summary.TotalLocked.Equals(coin.Coins{coin.NewCoinp(100, 0, "IOV")}) {
+		t.Fatalf("unexpected total locked: %v", summary.TotalLocked)
+	}
+	if !summary.TotalReleased.Equals(coin.Coins{coin.NewCoinp(50, 0, "IOV")}) {
+		t.Fatalf("unexpected total released: %v", summary.TotalReleased)
+	}
+	if summary.AverageRate != "0.133333333" {
+		t.Fatalf("unexpected average rate: %s", summary.AverageRate)
+	}
+	if len(summary.Calendar) != 1 || summary.Calendar[0].Month != "2030-03" ||
+		!summary.Calendar[0].Payout.Equals(coin.Coins{coin.NewCoinp(110, 0, "IOV")}) {
+		t.Fatalf("unexpected calendar: %+v", summary.Calendar)
+	}
+}
@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/indexer"
 	"github.com/iov-one/bns/cmd/bnsapi/models"
 	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
 	"github.com/iov-one/weave/cmd/bnsd/x/username"
 	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
 	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type OwnerHandler struct {
@@ -26,7 +35,7 @@ func (h *OwnerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	rawKey := LastChunk(r.URL.Path)
 	log.Print(r.URL.Path)
 	log.Print(rawKey)
-	key, err := WeaveAddressFromQuery(rawKey)
+	key, err := requestAddress(r, rawKey)
 	if err != nil {
 		log.Print(err)
 		JSONErr(w, http.StatusBadRequest, "wrong input, must be address")
@@ -93,3 +102,271 @@ func (h *ResolveHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		JSONErr(w, http.StatusBadRequest, "Bad username input")
 	}
 }
+
+// UsernameTargets returns username tokens that point to given target.
+type UsernameTargets interface {
+	Tokens(ctx context.Context, target username.BlockchainAddress) ([]util.KeyValue, error)
+}
+
+type UsernameReverseHandler struct {
+	Index UsernameTargets
+}
+
+// UsernameReverseHandler godoc
+// @Summary Returns a list of usernames that point to given blockchain address.
+// @Description The list is served from a local index that is either refreshed periodically or kept up to date by following new blocks.
+// @Tags Starname
+// @Param blockchain_id query string true "Blockchain ID, ex: iov-mainnet"
+// @Param address query string true "Address on that blockchain"
+// @Success 200 {object} handlers.MultipleObjectsResponse
+// @Failure 400
+// @Failure 500
+// @Failure 503
+// @Router /username/reverse [get]
+func (h *UsernameReverseHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	target := username.BlockchainAddress{
+		BlockchainID: q.Get("blockchain_id"),
+		Address:      q.Get("address"),
+	}
+	if target.BlockchainID == "" || target.Address == "" {
+		JSONErr(w, http.StatusBadRequest, "blockchain_id and address must be provided")
+		return
+	}
+
+	objects, err := h.Index.Tokens(r.Context(), target)
+	switch {
+	case err == nil:
+	case errors.ErrState.Is(err):
+		JSONErr(w, http.StatusServiceUnavailable, "index is not built yet")
+		return
+	default:
+		log.Printf("username target index: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	JSONResp(w, http.StatusOK, MultipleObjectsResponse{
+		Objects: objects,
+	})
+}
+
+// UsernameTargetIndex maps blockchain addresses to the username tokens that
+// point to them. bnsd does not index token targets, so the index is built
+// locally by reading all tokens. It is rebuilt when older than the configured
+// ttl.
+type UsernameTargetIndex struct {
+	index *ttlIndex
+}
+
+// NewUsernameTargetIndex returns an index that is rebuilt at most once every
+// ttl.
+func NewUsernameTargetIndex(bns client.BnsClient, ttl time.Duration) *UsernameTargetIndex {
+	return &UsernameTargetIndex{
+		index: newTTLIndex(ttl, func(ctx context.Context) (interface{}, error) {
+			return buildTargetIndex(ctx, bns)
+		}),
+	}
+}
+
+// Tokens returns all username tokens that point to given target.
+func (ix *UsernameTargetIndex) Tokens(ctx context.Context, target username.BlockchainAddress) ([]util.KeyValue, error) {
+	byTarget, err := ix.index.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tokens := byTarget.(map[username.BlockchainAddress][]util.KeyValue)[target]
+	if tokens == nil {
+		tokens = []util.KeyValue{}
+	}
+	return tokens, nil
+}
+
+// IndexedUsernameTargets serves username targets from the indexer.
+type IndexedUsernameTargets struct {
+	Bns     client.BnsClient
+	Indexer *indexer.Indexer
+}
+
+// Tokens returns all username tokens that point to given target, as of the
+// last block processed by the indexer.
+func (ix *IndexedUsernameTargets) Tokens(ctx context.Context, target username.BlockchainAddress) ([]util.KeyValue, error) {
+	value := indexer.TargetValue(target.BlockchainID, target.Address)
+	return indexedObjects(ctx, ix.Bns, ix.Indexer, indexer.UsernameTargetIndex, value, "/usernames",
+		func() orm.Model { return &username.Token{} })
+}
+
+func buildTargetIndex(ctx context.Context, bns client.BnsClient) (map[username.BlockchainAddress][]util.KeyValue, error) {
+	byTarget := make(map[username.BlockchainAddress][]util.KeyValue)
+	it := client.ABCIFullRangeQuery(ctx, bns, "/usernames", "")
+	for {
+		var t username.Token
+		switch key, err := it.Next(&t); {
+		case err == nil:
+			seen := make(map[username.BlockchainAddress]bool)
+			for _, target := range t.Targets {
+				if seen[target] {
+					continue
+				}
+				seen[target] = true
+				token := t
+				byTarget[target] = append(byTarget[target], util.KeyValue{
+					Key:   key,
+					Value: &token,
+				})
+			}
+		case errors.ErrIteratorDone.Is(err):
+			return byTarget, nil
+		default:
+			return nil, errors.Wrap(err, "usernames")
+		}
+	}
+}
+
+type UsernameAccountHandler struct {
+	Bns client.BnsClient
+}
+
+type usernameAccount struct {
+	Username *username.Token  `json:"username"`
+	Account  *account.Account `json:"account"`
+}
+
+// UsernameAccountHandler godoc
+// @Summary Returns a username together with the account of the same name in the iov domain.
+// @Description Either the username or the account is null if it does not exist.
+// @Tags Starname
+// @Param username path string true "username, with or without the *iov suffix. example: thematrix*iov"
+// @Success 200 {object} handlers.usernameAccount
+// @Failure 400
+// @Failure 404
+// @Failure 500
+// @Router /username/{username}/account [get]
+func (h *UsernameAccountHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := PathAfter(r.URL.Path, "/username/")
+	if !strings.HasSuffix(name, "/account") {
+		JSONErr(w, http.StatusNotFound, http.StatusText(http.StatusNotFound))
+		return
+	}
+	name = strings.TrimSuffix(name, "/account")
+	if name == "" || strings.Contains(name, "/") {
+		JSONErr(w, http.StatusBadRequest, "Bad username input")
+		return
+	}
+	// Usernames exist only in the iov domain and share the
+	// name*domain key format with accounts.
+	if !strings.HasSuffix(name, "*iov") {
+		name += "*iov"
+	}
+
+	var res usernameAccount
+	var token username.Token
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/usernames", []byte(name), &models.KeyModel{Model: &token}); {
+	case err == nil:
+		res.Username = &token
+	case errors.ErrNotFound.Is(err):
+	default:
+		log.Printf("username ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+	var acc account.Account
+	switch err := client.ABCIKeyQuery(r.Context(), h.Bns, "/accounts", []byte(name), &models.KeyModel{Model: &acc}); {
+	case err == nil:
+		res.Account = &acc
+	case errors.ErrNotFound.Is(err):
+	default:
+		log.Printf("account ABCI query: %s", err)
+		JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		return
+	}
+
+	if res.Username == nil && res.Account == nil {
+		JSONErr(w, http.StatusNotFound, "Username not found")
+		return
+	}
+	JSONResp(w, http.StatusOK, res)
+}
+
+type UsernamesHandler struct {
+	Bns client.BnsClient
+}
+
+type usernamesResponse struct {
+	MultipleObjectsResponse
+	// NextOffset is the offset value to request the next page with. It is
+	// not set if there are no more results.
+	NextOffset string `json:"next_offset,omitempty"`
+}
+
+// UsernamesHandler godoc
+// @Summary Returns a list of bnsd/x/username Token entities.
+// @Description The list is either the list of all usernames or all usernames of a given owner.
+// @Description If there are more results, next_offset contains the offset of the next page.
+// @Tags Starname
+// @Param owner query string false "The owner address format is either in iov address (iov1c9eprq0gxdmwl9u25j568zj7ylqgc7ajyu8wxr) or hex (C1721181E83376EF978AA4A9A38A5E27C08C7BB2)"
+// @Param offset query string false "Pagination offset, being the username to start from, ex: thematrix*iov"
+// @Param limit query int false "Maximum number of results, at most 1000"
+// @Success 200 {object} handlers.usernamesResponse
+// @Failure 400
+// @Failure 500
+// @Router /username/usernames [get]
+func (h *UsernamesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	limit := util.PaginationMaxItems
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > util.PaginationMaxItems {
+			JSONErr(w, http.StatusBadRequest, fmt.Sprintf("limit must be an integer between 1 and %d", util.PaginationMaxItems))
+			return
+		}
+		limit = n
+	}
+	offset := []byte(q.Get("offset"))
+
+	var it client.ABCIIterator
+	if o := q.Get("owner"); len(o) > 0 {
+		rawAddr, err := requestAddress(r, o)
+		if err != nil {
+			JSONErr(w, http.StatusBadRequest, "Owner address must be a valid address value..")
+			return
+		}
+		it = client.ABCIIndexRangeQueryFrom(r.Context(), h.Bns, "/usernames/owner", rawAddr, offset)
+	} else {
+		it = client.ABCIFullRangeQuery(r.Context(), h.Bns, "/usernames", fmt.Sprintf("%x:", offset))
+	}
+
+	// Fetch one more item than requested to know if there is a next page.
+	objects := make([]util.KeyValue, 0, limit+1)
+fetchUsernames:
+	for {
+		var t username.Token
+		switch key, err := it.Next(&t); {
+		case err == nil:
+			objects = append(objects, util.KeyValue{
+				Key:   key,
+				Value: &t,
+			})
+			if len(objects) == limit+1 {
+				break fetchUsernames
+			}
+		case errors.ErrIteratorDone.Is(err):
+			break fetchUsernames
+		default:
+			log.Printf("username usernames ABCI query: %s", err)
+			JSONErr(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+			return
+		}
+	}
+
+	var res usernamesResponse
+	if len(objects) > limit {
+		next := objects[limit].Key
+		// Skip the bucket prefix, being the characters before : (including separator)
+		res.NextOffset = string(next[bytes.Index(next, []byte(":"))+1:])
+		objects = objects[:limit]
+	}
+	res.Objects = objects
+	JSONResp(w, http.StatusOK, res)
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/indexer"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsernameReverseHandler(t *testing.T) {
+	target := username.BlockchainAddress{BlockchainID: "eth", Address: "0x1234"}
+	first := &username.Token{Targets: []username.BlockchainAddress{target}}
+	second := &username.Token{Targets: []username.BlockchainAddress{{BlockchainID: "eth", Address: "0x5678"}}}
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/usernames?range": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:alice*iov"), []byte("tokens:bob*iov")},
+					[]weave.Persistent{first, second}),
+				// Range query is continued from the last returned key.
+				strings.ToUpper(hex.EncodeToString([]byte(fmt.Sprintf("%x:", "bob*iov")))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:bob*iov")},
+					[]weave.Persistent{second}),
+			},
+		},
+	}
+	h := UsernameReverseHandler{Index: NewUsernameTargetIndex(bns, time.Minute)}
+
+	r, _ := http.NewRequest("GET", "/username/reverse?blockchain_id=eth&address=0x1234", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	bnsapitest.AssertAPIResponse(t, w, []util.KeyValue{
+		{
+			Key:   []byte("tokens:alice*iov"),
+			Value: first,
+		},
+	})
+}
+
+func TestUsernameReverseHandlerIndexed(t *testing.T) {
+	hexKey := func(s string) string { return strings.ToUpper(hex.EncodeToString([]byte(s))) }
+	target := username.BlockchainAddress{BlockchainID: "eth", Address: "0x1234"}
+	alice := &username.Token{Targets: []username.BlockchainAddress{target}}
+	bob := &username.Token{Targets: []username.BlockchainAddress{target}}
+	bns := &bnsapitest.BnsClientMock{
+		GetJSON: map[string]string{
+			"/status": `{"sync_info": {"latest_block_height": "5"}}`,
+		},
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/usernames?range@5": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:alice*iov"), []byte("tokens:bob*iov")},
+					[]weave.Persistent{alice, bob}),
+				hexKey(fmt.Sprintf("%x:", "bob*iov")): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:bob*iov")},
+					[]weave.Persistent{bob}),
+			},
+			"/usernames@5": {
+				hexKey("alice*iov"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:alice*iov")},
+					[]weave.Persistent{alice}),
+				// A token that the node does not return is skipped.
+				hexKey("bob*iov"): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+		},
+	}
+	var indexes []indexer.Index
+	for _, idx := range indexer.DefaultIndexes() {
+		if idx.Name == indexer.UsernameTargetIndex {
+			indexes = append(indexes, idx)
+		}
+	}
+	ix, err := indexer.New(bns, dbm.NewMemDB(), indexer.Config{Indexes: indexes})
+	if err != nil {
+		t.Fatalf("indexer: %s", err)
+	}
+	h := UsernameReverseHandler{Index: &IndexedUsernameTargets{Bns: bns, Indexer: ix}}
+
+	r, _ := http.NewRequest("GET", "/username/reverse?blockchain_id=eth&address=0x1234", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("want unavailable before the first sync, got %d %s", w.Code, w.Body)
+	}
+
+	if err := ix.Sync(context.Background()); err != nil {
+		t.Fatalf("indexer sync: %s", err)
+	}
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	bnsapitest.AssertAPIResponse(t, w, []util.KeyValue{
+		{
+			Key:   []byte("tokens:alice*iov"),
+			Value: alice,
+		},
+	})
+}
+
+func TestUsernameAccountHandler(t *testing.T) {
+	hexKey := func(s string) string { return strings.ToUpper(hex.EncodeToString([]byte(s))) }
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/usernames": {
+				hexKey("alice*iov"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("alice*iov")},
+					[]weave.Persistent{&username.Token{}}),
+				hexKey("bob*iov"):   bnsapitest.NewAbciQueryResponse(t, nil, nil),
+				hexKey("carol*iov"): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+			"/accounts": {
+				hexKey("alice*iov"): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+				hexKey("bob*iov"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("bob*iov")},
+					[]weave.Persistent{&account.Account{Name: "bob", Domain: "iov"}}),
+				hexKey("carol*iov"): bnsapitest.NewAbciQueryResponse(t, nil, nil),
+			},
+		},
+	}
+	h := UsernameAccountHandler{Bns: bns}
+
+	cases := map[string]struct {
+		path         string
+		wantCode     int
+		wantUsername bool
+		wantAccount  bool
+	}{
+		"username only":       {path: "/username/alice*iov/account", wantCode: http.StatusOK, wantUsername: true},
+		"account only":        {path: "/username/bob/account", wantCode: http.StatusOK, wantAccount: true},
+		"neither":             {path: "/username/carol/account", wantCode: http.StatusNotFound},
+		"unknown subresource": {path: "/username/alice/foo", wantCode: http.StatusNotFound},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", tc.path, nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != tc.wantCode {
+				t.Fatalf("want %d response, got %d: %s", tc.wantCode, w.Code, w.Body)
+			}
+			if tc.wantCode != http.StatusOK {
+				return
+			}
+			var res struct {
+				Username *username.Token
+				Account  *account.Account
+			}
+			if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+				t.Fatalf("cannot decode JSON response: %s", err)
+			}
+			if (res.Username != nil) != tc.wantUsername || (res.Account != nil) != tc.wantAccount {
+				t.Fatalf("unexpected response: %+v", res)
+			}
+		})
+	}
+}
+
+func TestUsernamesHandler(t *testing.T) {
+	alice := &username.Token{Targets: []username.BlockchainAddress{{BlockchainID: "eth", Address: "0x1234"}}}
+	bob := &username.Token{Targets: []username.BlockchainAddress{{BlockchainID: "eth", Address: "0x5678"}}}
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/usernames?range": {
+				strings.ToUpper(hex.EncodeToString([]byte(":"))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:alice*iov"), []byte("tokens:bob*iov")},
+					[]weave.Persistent{alice, bob}),
+			},
+		},
+	}
+	h := UsernamesHandler{Bns: bns}
+
+	r, _ := http.NewRequest("GET", "/username/usernames?limit=1", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	var res struct {
+		Objects    []json.RawMessage `json:"objects"`
+		NextOffset string            `json:"next_offset"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if len(res.Objects) != 1 {
+		t.Fatalf("want one object, got %d", len(res.Objects))
+	}
+	if res.NextOffset != "bob*iov" {
+		t.Fatalf("unexpected next offset: %q", res.NextOffset)
+	}
+
+	// A single range query returns at most 50 entities, so a larger
+	// page must be read with many queries.
+	var keys [][]byte
+	var tokens []weave.Persistent
+	for i := 0; i < 60; i++ {
+		keys = append(keys, []byte(fmt.Sprintf("tokens:user%02d*iov", i)))
+		tokens = append(tokens, alice)
+	}
+	bns.PostResults["/usernames?range"] = map[string]models.AbciQueryResponse{
+		strings.ToUpper(hex.EncodeToString([]byte(":"))): bnsapitest.NewAbciQueryResponse(t, keys[:50], tokens[:50]),
+		// Range query is continued from the last returned key.
+		strings.ToUpper(hex.EncodeToString([]byte(fmt.Sprintf("%x:", "user49*iov")))): bnsapitest.NewAbciQueryResponse(t, keys[49:], tokens[49:]),
+	}
+	r, _ = http.NewRequest("GET", "/username/usernames?limit=55", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("unexpected response: %d %s", w.Code, w.Body)
+	}
+	res.NextOffset = ""
+	if err := json.NewDecoder(w.Body).Decode(&res); err != nil {
+		t.Fatalf("cannot decode JSON response: %s", err)
+	}
+	if len(res.Objects) != 55 {
+		t.Fatalf("want 55 objects, got %d", len(res.Objects))
+	}
+	if res.NextOffset != "user55*iov" {
+		t.Fatalf("unexpected next offset: %q", res.NextOffset)
+	}
+
+	r, _ = http.NewRequest("GET", "/username/usernames?limit=0", nil)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("want bad request for invalid limit, got %d", w.Code)
+	}
+}
@@ -0,0 +1,148 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/tendermint/tendermint/crypto/tmhash"
+	"time"
+)
+
+// change is a modification of a single entity.
+type change struct {
+	// Bucket is the name of the bucket that the entity belongs to.
+	Bucket string
+	// Key is the entity key without the bucket prefix.
+	Key     []byte
+	Deleted bool
+	// TxIndex is the position of the transaction that made the change
+	// in the block or -1 if the change was made by a scheduled task.
+	TxIndex int
+}
+
+func latestHeight(ctx context.Context, bns client.BnsClient) (int64, error) {
+	var status struct {
+		SyncInfo struct {
+			LatestBlockHeight int64 `json:"latest_block_height,string"`
+		} `json:"sync_info"`
+	}
+	if err := bns.Get(ctx, "/status", &status); err != nil {
+		return 0, err
+	}
+	return status.SyncInfo.LatestBlockHeight, nil
+}
+
+type abciTag struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type deliverTxResult struct {
+	Code uint32    `json:"code"`
+	Tags []abciTag `json:"tags"`
+}
+
+type blockResults struct {
+	Height  int64 `json:"height,string"`
+	Results struct {
+		DeliverTx  []deliverTxResult `json:"DeliverTx"`
+		BeginBlock *struct {
+			Tags []abciTag `json:"tags"`
+		} `json:"BeginBlock"`
+	} `json:"results"`
+}
+
+// blockChanges returns all entities modified by the block at given height.
+// Changes are read from the tags that weave's KeyTagger attaches to each
+// transaction and to the scheduled tasks executed at the beginning of the
+// block. An entity modified more than once is returned once, with its final
+// state.
+func blockChanges(ctx context.Context, bns client.BnsClient, height int64) ([]change, error) {
+	var res blockResults
+	if err := bns.Get(ctx, fmt.Sprintf("/block_results?height=%d", height), &res); err != nil {
+		return nil, err
+	}
+
+	var changes []change
+	seen := make(map[string]int)
+	collect := func(txIndex int, tags []abciTag) {
+		for _, t := range tags {
+			c, ok := parseTag(t)
+			if !ok {
+				continue
+			}
+			c.TxIndex = txIndex
+			id := c.Bucket + ":" + string(c.Key)
+			if i, ok := seen[id]; ok {
+				changes[i].Deleted = c.Deleted
+				changes[i].TxIndex = c.TxIndex
+				continue
+			}
+			seen[id] = len(changes)
+			changes = append(changes, c)
+		}
+	}
+	if res.Results.BeginBlock != nil {
+		collect(-1, res.Results.BeginBlock.Tags)
+	}
+	for i, tx := range res.Results.DeliverTx {
+		// Failed transactions do not modify the state.
+		if tx.Code != 0 {
+			continue
+		}
+		collect(i, tx.Tags)
+	}
+	return changes, nil
+}
+
+type blockInfo struct {
+	Time time.Time
+	// TxHashes are the hashes of the block transactions, in the order
+	// of execution.
+	TxHashes [][]byte
+}
+
+func fetchBlockInfo(ctx context.Context, bns client.BnsClient, height int64) (*blockInfo, error) {
+	var res struct {
+		Block struct {
+			Header struct {
+				Time time.Time `json:"time"`
+			} `json:"header"`
+			Data struct {
+				Txs [][]byte `json:"txs"`
+			} `json:"data"`
+		} `json:"block"`
+	}
+	if err := bns.Get(ctx, fmt.Sprintf("/block?height=%d", height), &res); err != nil {
+		return nil, err
+	}
+	info := blockInfo{Time: res.Block.Header.Time}
+	for _, tx := range res.Block.Data.Txs {
+		info.TxHashes = append(info.TxHashes, tmhash.Sum(tx))
+	}
+	return &info, nil
+}
+
+// parseTag decodes a key change tag. Tags that do not describe a change of
+// a bucket entity, ie the action tag, are ignored.
+func parseTag(t abciTag) (change, bool) {
+	var deleted bool
+	switch string(t.Value) {
+	case "s":
+	case "d":
+		deleted = true
+	default:
+		return change{}, false
+	}
+	raw, err := hex.DecodeString(string(t.Key))
+	if err != nil {
+		return change{}, false
+	}
+	i := bytes.IndexByte(raw, ':')
+	if i <= 0 {
+		return change{}, false
+	}
+	return change{Bucket: string(raw[:i]), Key: raw[i+1:], Deleted: deleted}, true
+}
@@ -0,0 +1,354 @@
+package indexer
+
+import (
+	"bytes"
+	"context"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Index declares a secondary index of entities stored in a single bnsd
+// bucket.
+type Index struct {
+	// Name identifies the index in the store and in lookups. It must be
+	// unique and must not contain "/".
+	Name string
+	// Bucket is the name of the bnsd bucket that entities are read from,
+	// ie "account". It is the prefix of the entity keys in the state.
+	Bucket string
+	// Path is the ABCI query path of the bucket, ie /accounts.
+	Path string
+	// NewModel returns an empty entity of the bucket.
+	NewModel func() orm.Model
+	// Values returns all values that given entity is indexed by. An
+	// entity that should not be indexed returns no values.
+	Values func(orm.Model) ([][]byte, error)
+}
+
+// Config declares what the indexer maintains.
+type Config struct {
+	Indexes  []Index
+	Journals []Journal
+	// StartHeight is the height at which an empty store is populated with
+	// the state of all indexed buckets. Indexing continues from the next
+	// block. Zero means the latest block.
+	StartHeight int64
+}
+
+// Indexer follows new blocks and maintains secondary indexes and change
+// journals of the entities modified by them. Data is kept in a local
+// key/value store. Indexing resumes from the last processed height, stored
+// in the same database.
+//
+// Entities are read at the height of the block that modified them, so the
+// node queried must not prune the state of the blocks being indexed. Indexes
+// and journals added to a store that was already populated are not
+// backfilled.
+type Indexer struct {
+	bns         client.BnsClient
+	db          dbm.DB
+	startHeight int64
+	indexes     map[string]Index
+	journals    map[string]Journal
+	buckets     map[string]*bucket
+
+	// mu serializes synchronization, so that the store is modified by a
+	// single writer.
+	mu sync.Mutex
+	// view is held for writing while a block is written, so that readers
+	// see the store and its checkpoint at the same block.
+	view sync.RWMutex
+}
+
+// bucket groups everything maintained for entities of a single bucket.
+type bucket struct {
+	path     string
+	newModel func() orm.Model
+	indexes  []Index
+	journals []Journal
+}
+
+// New returns an indexer that maintains the configured indexes and journals
+// in the database.
+func New(bns client.BnsClient, db dbm.DB, conf Config) (*Indexer, error) {
+	ix := &Indexer{
+		bns:         bns,
+		db:          db,
+		startHeight: conf.StartHeight,
+		indexes:     make(map[string]Index),
+		journals:    make(map[string]Journal),
+		buckets:     make(map[string]*bucket),
+	}
+	for _, idx := range conf.Indexes {
+		if idx.Values == nil {
+			return nil, errors.Wrapf(errors.ErrInput, "index %q is incomplete", idx.Name)
+		}
+		if _, ok := ix.indexes[idx.Name]; ok {
+			return nil, errors.Wrapf(errors.ErrDuplicate, "index %q", idx.Name)
+		}
+		b, err := ix.bucket(idx.Name, idx.Bucket, idx.Path, idx.NewModel)
+		if err != nil {
+			return nil, errors.Wrapf(err, "index %q", idx.Name)
+		}
+		ix.indexes[idx.Name] = idx
+		b.indexes = append(b.indexes, idx)
+	}
+	for _, j := range conf.Journals {
+		if _, ok := ix.journals[j.Name]; ok {
+			return nil, errors.Wrapf(errors.ErrDuplicate, "journal %q", j.Name)
+		}
+		b, err := ix.bucket(j.Name, j.Bucket, j.Path, j.NewModel)
+		if err != nil {
+			return nil, errors.Wrapf(err, "journal %q", j.Name)
+		}
+		ix.journals[j.Name] = j
+		b.journals = append(b.journals, j)
+	}
+	return ix, nil
+}
+
+// bucket returns the declaration of given bucket, validating that it is
+// consistent with the declarations of other indexes and journals.
+func (ix *Indexer) bucket(name, bucketName, path string, newModel func() orm.Model) (*bucket, error) {
+	switch {
+	case name == "" || strings.Contains(name, "/"):
+		return nil, errors.Wrap(errors.ErrInput, "invalid name")
+	case bucketName == "" || path == "" || newModel == nil:
+		return nil, errors.Wrap(errors.ErrInput, "incomplete declaration")
+	}
+	b, ok := ix.buckets[bucketName]
+	if !ok {
+		b = &bucket{path: path, newModel: newModel}
+		ix.buckets[bucketName] = b
+	}
+	if b.path != path {
+		return nil, errors.Wrapf(errors.ErrInput, "bucket %q is queried with both %s and %s", bucketName, b.path, path)
+	}
+	return b, nil
+}
+
+// Height returns the height of the last block processed by the indexer or
+// zero if nothing was indexed yet.
+func (ix *Indexer) Height() int64 {
+	ix.view.RLock()
+	defer ix.view.RUnlock()
+	return loadCheckpoint(ix.db)
+}
+
+// Lookup returns the keys of all entities indexed with given value, in
+// lexicographical order, together with the height of the last processed
+// block that the keys are valid at. Keys do not contain the bucket prefix
+// and can be used with client.ABCIKeyQuery directly. Until the first block
+// is processed, ErrState is returned.
+func (ix *Indexer) Lookup(index string, value []byte) ([][]byte, int64, error) {
+	if _, ok := ix.indexes[index]; !ok {
+		return nil, 0, errors.Wrapf(errors.ErrNotFound, "index %q", index)
+	}
+
+	ix.view.RLock()
+	defer ix.view.RUnlock()
+
+	height := loadCheckpoint(ix.db)
+	if height == 0 {
+		return nil, 0, errors.Wrap(errors.ErrState, "nothing indexed yet")
+	}
+	prefix := entryPrefix(index, value)
+	it := dbm.IteratePrefix(ix.db, prefix)
+	defer it.Close()
+
+	var keys [][]byte
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, append([]byte(nil), it.Value()...))
+	}
+	return keys, height, nil
+}
+
+// Run synchronizes the indexes with the chain every interval until the
+// context is cancelled. Synchronization errors are logged and retried.
+func (ix *Indexer) Run(ctx context.Context, interval time.Duration) error {
+	for {
+		if err := ix.Sync(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("indexer sync: %s", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Sync processes all blocks created since the last checkpoint, up to the
+// latest one. An empty store is first populated with the state of all
+// indexed buckets at the start height.
+func (ix *Indexer) Sync(ctx context.Context) error {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	latest, err := latestHeight(ctx, ix.bns)
+	if err != nil {
+		return errors.Wrap(err, "latest height")
+	}
+	checkpoint := loadCheckpoint(ix.db)
+	if checkpoint == 0 {
+		start := ix.startHeight
+		if start == 0 {
+			start = latest
+		}
+		if start > latest {
+			return errors.Wrapf(errors.ErrInput, "start height %d is greater than the latest height %d", start, latest)
+		}
+		if err := ix.bootstrap(ctx, start); err != nil {
+			return errors.Wrapf(err, "bootstrap at %d", start)
+		}
+		checkpoint = start
+	}
+	for h := checkpoint + 1; h <= latest; h++ {
+		if err := ix.processBlock(ctx, h); err != nil {
+			return errors.Wrapf(err, "block %d", h)
+		}
+	}
+	return nil
+}
+
+// bootstrap indexes all entities of the indexed buckets as found at given
+// height. Journals record that state as the initial one.
+func (ix *Indexer) bootstrap(ctx context.Context, height int64) error {
+	var blockTime time.Time
+	if len(ix.journals) != 0 {
+		block, err := fetchBlockInfo(ctx, ix.bns, height)
+		if err != nil {
+			return errors.Wrap(err, "block info")
+		}
+		blockTime = block.Time
+	}
+
+	b := ix.db.NewBatch()
+	defer b.Close()
+
+	ctx = client.WithHeight(ctx, height)
+	for name, bk := range ix.buckets {
+		prefix := []byte(name + ":")
+		it := client.ABCIFullRangeQuery(ctx, ix.bns, bk.path, "")
+	iterate:
+		for {
+			m := bk.newModel()
+			switch key, err := it.Next(m); {
+			case err == nil:
+				key = bytes.TrimPrefix(key, prefix)
+				if err := ix.put(b, bk, key, m); err != nil {
+					return errors.Wrapf(err, "bucket %q", name)
+				}
+				if err := ix.record(b, bk, key, m, Change{Height: height, Time: blockTime, Initial: true}); err != nil {
+					return errors.Wrapf(err, "bucket %q", name)
+				}
+			case errors.ErrIteratorDone.Is(err):
+				break iterate
+			default:
+				return errors.Wrapf(err, "bucket %q", name)
+			}
+		}
+	}
+	saveCheckpoint(b, height)
+	ix.commit(b)
+	return nil
+}
+
+// processBlock updates the indexes and journals with all entities modified
+// by the block at given height.
+func (ix *Indexer) processBlock(ctx context.Context, height int64) error {
+	changes, err := blockChanges(ctx, ix.bns, height)
+	if err != nil {
+		return errors.Wrap(err, "block changes")
+	}
+
+	b := ix.db.NewBatch()
+	defer b.Close()
+
+	var block *blockInfo
+	ctx = client.WithHeight(ctx, height)
+	for _, c := range changes {
+		bk, ok := ix.buckets[c.Bucket]
+		if !ok {
+			continue
+		}
+		var m orm.Model
+		if !c.Deleted {
+			m = bk.newModel()
+			switch err := client.ABCIKeyQuery(ctx, ix.bns, bk.path, c.Key, &models.KeyModel{Model: m}); {
+			case err == nil:
+			case errors.ErrNotFound.Is(err):
+				m = nil
+			default:
+				return errors.Wrapf(err, "query %s %q", bk.path, c.Key)
+			}
+		}
+		if err := ix.put(b, bk, c.Key, m); err != nil {
+			return err
+		}
+
+		if len(bk.journals) == 0 {
+			continue
+		}
+		if block == nil {
+			if block, err = fetchBlockInfo(ctx, ix.bns, height); err != nil {
+				return errors.Wrap(err, "block info")
+			}
+		}
+		ch := Change{Height: height, Time: block.Time}
+		if c.TxIndex >= 0 && c.TxIndex < len(block.TxHashes) {
+			ch.TxHash = block.TxHashes[c.TxIndex]
+		}
+		if err := ix.record(b, bk, c.Key, m, ch); err != nil {
+			return err
+		}
+	}
+	saveCheckpoint(b, height)
+	ix.commit(b)
+	return nil
+}
+
+// commit writes the batch of a processed block.
+func (ix *Indexer) commit(b dbm.Batch) {
+	ix.view.Lock()
+	defer ix.view.Unlock()
+	b.WriteSync()
+}
+
+// put replaces all entries of the entity with given key in the bucket
+// indexes with the entries of the new entity state. A nil entity removes all
+// entries.
+func (ix *Indexer) put(b dbm.Batch, bk *bucket, key []byte, m orm.Model) error {
+	for _, idx := range bk.indexes {
+		for _, v := range loadValues(ix.db, idx.Name, key) {
+			b.Delete(entryKey(idx.Name, v, key))
+		}
+		if m == nil {
+			b.Delete(valuesKey(idx.Name, key))
+			continue
+		}
+
+		values, err := idx.Values(m)
+		if err != nil {
+			return errors.Wrapf(err, "index %q values", idx.Name)
+		}
+		for _, v := range values {
+			b.Set(entryKey(idx.Name, v, key), key)
+		}
+		if len(values) == 0 {
+			b.Delete(valuesKey(idx.Name, key))
+			continue
+		}
+		if err := saveValues(b, idx.Name, key, values); err != nil {
+			return errors.Wrapf(err, "index %q", idx.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package indexer
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/errors"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestIndexerSync(t *testing.T) {
+	hexKey := func(s string) string { return strings.ToUpper(hex.EncodeToString([]byte(s))) }
+	token := func(addresses ...string) *username.Token {
+		t := &username.Token{}
+		for _, a := range addresses {
+			t.Targets = append(t.Targets, username.BlockchainAddress{BlockchainID: "eth", Address: a})
+		}
+		return t
+	}
+	status := func(height int64) string {
+		return fmt.Sprintf(`{"sync_info": {"latest_block_height": "%d"}}`, height)
+	}
+
+	bns := &bnsapitest.BnsClientMock{
+		GetJSON: map[string]string{
+			"/status": status(5),
+			"/block_results?height=6": blockResultsJSON(t, 6,
+				[]tx{
+					{Tags: map[string]string{"tokens:alice*iov": "s", "tokens:bob*iov": "s", "action": "username/update"}},
+					{Code: 1, Tags: map[string]string{"tokens:carol*iov": "s"}},
+				}),
+			"/block_results?height=7": blockResultsJSON(t, 7,
+				[]tx{
+					{Tags: map[string]string{"tokens:bob*iov": "d"}},
+				}),
+		},
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/usernames?range": {
+				"": bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:alice*iov")},
+					[]weave.Persistent{token("0x1")}),
+				hexKey(fmt.Sprintf("%x:", "alice*iov")): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:alice*iov")},
+					[]weave.Persistent{token("0x1")}),
+			},
+			"/usernames@6": {
+				hexKey("alice*iov"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:alice*iov")},
+					[]weave.Persistent{token("0x2")}),
+				hexKey("bob*iov"): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("tokens:bob*iov")},
+					[]weave.Persistent{token("0x1", "0x2")}),
+			},
+		},
+	}
+
+	var indexes []Index
+	for _, idx := range DefaultIndexes() {
+		if idx.Name == UsernameTargetIndex {
+			indexes = append(indexes, idx)
+		}
+	}
+	ix, err := New(bns, dbm.NewMemDB(), Config{Indexes: indexes})
+	if err != nil {
+		t.Fatalf("new indexer: %s", err)
+	}
+
+	assertLookup := func(t testing.TB, address string, want ...string) {
+		t.Helper()
+		keys, height, err := ix.Lookup(UsernameTargetIndex, TargetValue("eth", address))
+		if err != nil {
+			t.Fatalf("lookup: %s", err)
+		}
+		if height != ix.Height() {
+			t.Fatalf("want keys at %d, got %d", ix.Height(), height)
+		}
+		var got []string
+		for _, k := range keys {
+			got = append(got, string(k))
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("want %q keys for %s, got %q", want, address, got)
+		}
+	}
+
+	if _, _, err := ix.Lookup(UsernameTargetIndex, TargetValue("eth", "0x1")); !errors.ErrState.Is(err) {
+		t.Fatalf("want lookup before the first sync to fail, got %v", err)
+	}
+
+	ctx := context.Background()
+	if err := ix.Sync(ctx); err != nil {
+		t.Fatalf("bootstrap: %s", err)
+	}
+	if h := ix.Height(); h != 5 {
+		t.Fatalf("want checkpoint at 5, got %d", h)
+	}
+	assertLookup(t, "0x1", "alice*iov")
+	assertLookup(t, "0x2")
+
+	bns.GetJSON["/status"] = status(7)
+	if err := ix.Sync(ctx); err != nil {
+		t.Fatalf("sync: %s", err)
+	}
+	if h := ix.Height(); h != 7 {
+		t.Fatalf("want checkpoint at 7, got %d", h)
+	}
+	assertLookup(t, "0x1")
+	assertLookup(t, "0x2", "alice*iov")
+
+	if _, _, err := ix.Lookup("unknown", nil); err == nil {
+		t.Fatal("unknown index lookup must fail")
+	}
+}
+
+func TestNewRejectsDuplicatedIndex(t *testing.T) {
+	indexes := DefaultIndexes()
+	indexes = append(indexes, indexes[0])
+	if _, err := New(&bnsapitest.BnsClientMock{}, dbm.NewMemDB(), Config{Indexes: indexes}); err == nil {
+		t.Fatal("duplicated index name must be rejected")
+	}
+}
+
+type tx struct {
+	Code uint32
+	Tags map[string]string
+}
+
+// blockResultsJSON returns the block results as serialized by tendermint.
+// Tag keys are given as raw database keys.
+func blockResultsJSON(t testing.TB, height int64, txs []tx) string {
+	t.Helper()
+	var res blockResults
+	res.Height = height
+	for _, tx := range txs {
+		var tags []abciTag
+		for k, v := range tx.Tags {
+			key := k
+			if strings.Contains(k, ":") {
+				key = strings.ToUpper(hex.EncodeToString([]byte(k)))
+			}
+			tags = append(tags, abciTag{Key: []byte(key), Value: []byte(v)})
+		}
+		res.Results.DeliverTx = append(res.Results.DeliverTx, deliverTxResult{Code: tx.Code, Tags: tags})
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		t.Fatalf("serialize block results: %s", err)
+	}
+	return string(raw)
+}
@@ -0,0 +1,79 @@
+package indexer
+
+import (
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	"github.com/iov-one/weave/x/multisig"
+)
+
+// Names of the indexes provided by DefaultIndexes.
+const (
+	UsernameTargetIndex      = "username_target"
+	MultisigParticipantIndex = "multisig_participant"
+)
+
+// DefaultIndexes returns the indexes of the bnsd state that bnsd does not
+// provide itself.
+func DefaultIndexes() []Index {
+	return []Index{
+		{
+			Name:     UsernameTargetIndex,
+			Bucket:   "tokens",
+			Path:     "/usernames",
+			NewModel: func() orm.Model { return &username.Token{} },
+			Values: func(m orm.Model) ([][]byte, error) {
+				t, ok := m.(*username.Token)
+				if !ok {
+					return nil, errors.Wrapf(errors.ErrType, "%T", m)
+				}
+				values := make([][]byte, 0, len(t.Targets))
+				for _, target := range t.Targets {
+					values = append(values, TargetValue(target.BlockchainID, target.Address))
+				}
+				return values, nil
+			},
+		},
+		{
+			Name:     MultisigParticipantIndex,
+			Bucket:   "contracts",
+			Path:     "/contracts",
+			NewModel: func() orm.Model { return &multisig.Contract{} },
+			Values: func(m orm.Model) ([][]byte, error) {
+				c, ok := m.(*multisig.Contract)
+				if !ok {
+					return nil, errors.Wrapf(errors.ErrType, "%T", m)
+				}
+				values := make([][]byte, 0, len(c.Participants))
+				for _, p := range c.Participants {
+					values = append(values, p.Signature)
+				}
+				return values, nil
+			},
+		},
+	}
+}
+
+// Names of the journals provided by DefaultJournals.
+const (
+	AccountJournal = "account"
+)
+
+// DefaultJournals returns the journals of bnsd entities whose history is
+// served by the API.
+func DefaultJournals() []Journal {
+	return []Journal{
+		{
+			Name:     AccountJournal,
+			Bucket:   "account",
+			Path:     "/accounts",
+			NewModel: func() orm.Model { return &account.Account{} },
+		},
+	}
+}
+
+// TargetValue returns the value that username targets are indexed by.
+func TargetValue(blockchainID, address string) []byte {
+	return []byte(blockchainID + "\x00" + address)
+}
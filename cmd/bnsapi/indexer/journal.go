@@ -0,0 +1,96 @@
+package indexer
+
+import (
+	"encoding/json"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	"time"
+)
+
+// Journal declares a bucket whose entity changes are recorded, so that the
+// history of each entity can be read.
+type Journal struct {
+	// Name identifies the journal in the store and in history reads. It
+	// must be unique and must not contain "/".
+	Name string
+	// Bucket is the name of the bnsd bucket that entities are read from,
+	// ie "account".
+	Bucket string
+	// Path is the ABCI query path of the bucket, ie /accounts.
+	Path string
+	// NewModel returns an empty entity of the bucket.
+	NewModel func() orm.Model
+}
+
+// Change is the state of an entity after it was modified.
+//
+// An entity state is read once per block, so when more than one
+// transaction of a block modify the same entity, only the state after the
+// last one is recorded.
+type Change struct {
+	Height int64     `json:"height"`
+	Time   time.Time `json:"time"`
+	// TxHash is the hash of the transaction that made the change. It is
+	// empty if the change was made by a scheduled task or if this is the
+	// initial state.
+	TxHash []byte `json:"tx_hash,omitempty"`
+	// Initial is true for the state found when the store was populated.
+	// It is not known when that state was created.
+	Initial bool `json:"initial,omitempty"`
+	// Value is the serialized entity or nil if the entity was deleted.
+	Value []byte `json:"value,omitempty"`
+}
+
+// History returns all recorded changes of the entity with given key, from
+// the oldest, together with the height of the last processed block that the
+// history is complete up to. The key does not contain the bucket prefix.
+// Until the first block is processed, ErrState is returned.
+func (ix *Indexer) History(journal string, key []byte) ([]Change, int64, error) {
+	if _, ok := ix.journals[journal]; !ok {
+		return nil, 0, errors.Wrapf(errors.ErrNotFound, "journal %q", journal)
+	}
+
+	ix.view.RLock()
+	defer ix.view.RUnlock()
+
+	height := loadCheckpoint(ix.db)
+	if height == 0 {
+		return nil, 0, errors.Wrap(errors.ErrState, "nothing indexed yet")
+	}
+	it := dbm.IteratePrefix(ix.db, changePrefix(journal, key))
+	defer it.Close()
+
+	var changes []Change
+	for ; it.Valid(); it.Next() {
+		var c Change
+		if err := json.Unmarshal(it.Value(), &c); err != nil {
+			return nil, 0, errors.Wrapf(err, "corrupted change %q", it.Key())
+		}
+		changes = append(changes, c)
+	}
+	return changes, height, nil
+}
+
+// record appends the new entity state to all journals of the bucket. A nil
+// entity records a deletion.
+func (ix *Indexer) record(b dbm.Batch, bk *bucket, key []byte, m orm.Model, c Change) error {
+	if len(bk.journals) == 0 {
+		return nil
+	}
+	if m != nil {
+		raw, err := m.Marshal()
+		if err != nil {
+			return errors.Wrap(err, "serialize entity")
+		}
+		c.Value = raw
+	}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return errors.Wrap(err, "serialize change")
+	}
+	for _, j := range bk.journals {
+		b.Set(changeKey(j.Name, key, c.Height), raw)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package indexer
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/weave/errors"
+	dbm "github.com/tendermint/tendermint/libs/db"
+	"strconv"
+)
+
+// Store layout. Values and keys are hex encoded so that they never contain
+// the separator and the lexicographical order of the raw bytes is kept.
+//
+//	checkpoint                       -> last indexed height, decimal
+//	i/<index>/<hex value>/<hex key>  -> entity key
+//	v/<index>/<hex key>              -> JSON list of the values the entity is indexed by
+//	h/<journal>/<hex key>/<height>   -> JSON serialized Change, height is fixed width hex
+var checkpointKey = []byte("checkpoint")
+
+func changePrefix(journal string, key []byte) []byte {
+	return []byte("h/" + journal + "/" + hex.EncodeToString(key) + "/")
+}
+
+func changeKey(journal string, key []byte, height int64) []byte {
+	return append(changePrefix(journal, key), fmt.Sprintf("%016x", height)...)
+}
+
+func entryPrefix(index string, value []byte) []byte {
+	return []byte("i/" + index + "/" + hex.EncodeToString(value) + "/")
+}
+
+func entryKey(index string, value, key []byte) []byte {
+	return append(entryPrefix(index, value), hex.EncodeToString(key)...)
+}
+
+func valuesKey(index string, key []byte) []byte {
+	return []byte("v/" + index + "/" + hex.EncodeToString(key))
+}
+
+func loadValues(db dbm.DB, index string, key []byte) [][]byte {
+	raw := db.Get(valuesKey(index, key))
+	if raw == nil {
+		return nil
+	}
+	var values [][]byte
+	if err := json.Unmarshal(raw, &values); err != nil {
+		// The store is written only by the indexer, so this is a
+		// programming error.
+		panic(errors.Wrapf(err, "corrupted values of %q", valuesKey(index, key)))
+	}
+	return values
+}
+
+func saveValues(b dbm.Batch, index string, key []byte, values [][]byte) error {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return errors.Wrap(err, "serialize values")
+	}
+	b.Set(valuesKey(index, key), raw)
+	return nil
+}
+
+func loadCheckpoint(db dbm.DB) int64 {
+	raw := db.Get(checkpointKey)
+	if raw == nil {
+		return 0
+	}
+	height, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		panic(errors.Wrapf(err, "corrupted checkpoint %q", raw))
+	}
+	return height
+}
+
+func saveCheckpoint(b dbm.Batch, height int64) {
+	b.Set(checkpointKey, []byte(strconv.FormatInt(height, 10)))
+}
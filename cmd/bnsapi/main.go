@@ -1,32 +1,34 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/iov-one/bns/cmd/bnsapi/client"
 	"github.com/iov-one/bns/cmd/bnsapi/docs"
 	"github.com/iov-one/bns/cmd/bnsapi/handlers"
+	"github.com/iov-one/bns/cmd/bnsapi/indexer"
 	"github.com/iov-one/bns/cmd/bnsapi/util"
 	httpSwagger "github.com/swaggo/http-swagger"
+	dbm "github.com/tendermint/tendermint/libs/db"
 
 	"log"
 	"net/http"
 	"os"
-
-	"github.com/iov-one/weave/cmd/bnsd/x/account"
-	"github.com/iov-one/weave/cmd/bnsd/x/preregistration"
-	"github.com/iov-one/weave/cmd/bnsd/x/qualityscore"
-	"github.com/iov-one/weave/cmd/bnsd/x/termdeposit"
-	"github.com/iov-one/weave/cmd/bnsd/x/username"
-	"github.com/iov-one/weave/gconf"
-	"github.com/iov-one/weave/migration"
-	"github.com/iov-one/weave/x/cash"
-	"github.com/iov-one/weave/x/msgfee"
-	"github.com/iov-one/weave/x/txfee"
+	"strconv"
+	"time"
 )
 
 type Configuration struct {
 	HTTP       string
 	Tendermint string
+	Network    string
+	IndexDir   string
+	// IndexStartHeight is the height that an empty index store is
+	// populated at. Zero means the latest block.
+	IndexStartHeight int64
+	// IndexTTL is how long the indexes built in memory are served before
+	// being rebuilt. Zero means the handlers default.
+	IndexTTL time.Duration
 }
 
 // @title BNSAPI documentation
@@ -37,7 +39,23 @@ func main() {
 
 	conf := Configuration{
 		HTTP:       env("HTTP", ":8000"),
-		Tendermint: env("TENDERMINT", "http://localhost:26657")}
+		Tendermint: env("TENDERMINT", "http://localhost:26657"),
+		Network:    env("NETWORK", ""),
+		IndexDir:   env("INDEX_DIR", "")}
+	if h := env("INDEX_START_HEIGHT", ""); h != "" {
+		height, err := strconv.ParseInt(h, 10, 64)
+		if err != nil || height < 0 {
+			log.Fatalf("invalid INDEX_START_HEIGHT %q", h)
+		}
+		conf.IndexStartHeight = height
+	}
+	if v := env("INDEX_TTL", ""); v != "" {
+		ttl, err := time.ParseDuration(v)
+		if err != nil || ttl <= 0 {
+			log.Fatalf("invalid INDEX_TTL %q", v)
+		}
+		conf.IndexTTL = ttl
+	}
 
 	if err := run(conf); err != nil {
 		log.Fatal(err)
@@ -58,49 +76,47 @@ func env(name, fallback string) string {
 	return fallback
 }
 
+// indexerInterval is how often the indexer checks for new blocks.
+const indexerInterval = 5 * time.Second
+
 func run(conf Configuration) error {
 	bnscli := client.NewHTTPBnsClient(conf.Tendermint)
+	network, err := handlers.NetworkByName(conf.Network)
+	if err != nil {
+		return fmt.Errorf("network: %s", err)
+	}
 
-	gconfConfigurations := map[string]func() gconf.Configuration{
-		"account":         func() gconf.Configuration { return &account.Configuration{} },
-		"cash":            func() gconf.Configuration { return &cash.Configuration{} },
-		"migration":       func() gconf.Configuration { return &migration.Configuration{} },
-		"msgfee":          func() gconf.Configuration { return &msgfee.Configuration{} },
-		"preregistration": func() gconf.Configuration { return &preregistration.Configuration{} },
-		"qualityscore":    func() gconf.Configuration { return &qualityscore.Configuration{} },
-		"termdeposit":     func() gconf.Configuration { return &termdeposit.Configuration{} },
-		"txfee":           func() gconf.Configuration { return &txfee.Configuration{} },
-		"username":        func() gconf.Configuration { return &username.Configuration{} },
+	opts := handlers.Options{Network: network, IndexTTL: conf.IndexTTL}
+	if conf.IndexDir != "" {
+		if conf.IndexStartHeight == 0 {
+			log.Print("INDEX_START_HEIGHT not set, an empty index store records history from the latest block only")
+		}
+		db, err := dbm.NewGoLevelDB("bnsapi-index", conf.IndexDir)
+		if err != nil {
+			return fmt.Errorf("index store: %s", err)
+		}
+		defer db.Close()
+		ix, err := indexer.New(bnscli, db, indexer.Config{
+			Indexes:     indexer.DefaultIndexes(),
+			Journals:    indexer.DefaultJournals(),
+			StartHeight: conf.IndexStartHeight,
+		})
+		if err != nil {
+			return fmt.Errorf("indexer: %s", err)
+		}
+		go ix.Run(context.Background(), indexerInterval)
+		opts.Indexer = ix
 	}
 
-	rt := http.NewServeMux()
-	rt.Handle("/info", &handlers.InfoHandler{})
-	rt.Handle("/blocks/", &handlers.BlocksHandler{Bns: bnscli})
-	rt.Handle("/account/domains", &handlers.DomainsHandler{Bns: bnscli})
-	rt.Handle("/account/accounts", &handlers.AccountsHandler{Bns: bnscli})
-	rt.Handle("/account/resolve/", &handlers.AccountResolveHandler{Bns: bnscli})
-	rt.Handle("/nonce/address/", &handlers.NonceAddressHandler{Bns: bnscli})
-	rt.Handle("/nonce/pubkey/", &handlers.NoncePubKeyHandler{Bns: bnscli})
-	rt.Handle("/username/owner/", &handlers.OwnerHandler{Bns: bnscli})
-	rt.Handle("/username/resolve/", &handlers.ResolveHandler{Bns: bnscli})
-	rt.Handle("/cash/balances", &handlers.CashBalanceHandler{Bns: bnscli})
-	rt.Handle("/termdeposit/contracts", &handlers.ContractsHandler{Bns: bnscli})
-	rt.Handle("/termdeposit/deposits", &handlers.DepositsHandler{Bns: bnscli})
-	rt.Handle("/multisig/contracts", &handlers.MultisigContractsHandler{Bns: bnscli})
-	rt.Handle("/escrow/escrows", &handlers.EscrowEscrowsHandler{Bns: bnscli})
-	rt.Handle("/gov/proposals", &handlers.GovProposalsHandler{Bns: bnscli})
-	rt.Handle("/gov/votes", &handlers.GovVotesHandler{Bns: bnscli})
-	rt.Handle("/gconf/", &handlers.GconfHandler{Bns: bnscli, Confs: gconfConfigurations})
-	rt.Handle("/msgfee/msgfees", &handlers.MsgFeeHandler{Bns: bnscli})
-	rt.Handle("/tx/submit", &handlers.TxSubmitHandler{Bns: bnscli})
-	rt.Handle("/", &handlers.DefaultHandler{})
+	mux := http.NewServeMux()
+	handlers.Register(mux, bnscli, opts)
 
 	docs.SwaggerInfo.Title = "IOV Name Service Rest API"
 	docs.SwaggerInfo.Version = util.BuildVersion
 	docsUrl := fmt.Sprintf("doc.json")
-	rt.Handle("/docs/", httpSwagger.Handler(httpSwagger.URL(docsUrl)))
+	mux.Handle("/docs/", httpSwagger.Handler(httpSwagger.URL(docsUrl)))
 
-	if err := http.ListenAndServe(conf.HTTP, rt); err != nil {
+	if err := http.ListenAndServe(conf.HTTP, mux); err != nil {
 		return fmt.Errorf("http server: %s", err)
 	}
 	return nil
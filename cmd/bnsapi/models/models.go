@@ -16,4 +16,6 @@ type AbciQueryResponse struct {
 type AbciQueryResponseResponse struct {
 	Key   []byte
 	Value []byte
+	// Height is the block height that the state was read at.
+	Height int64 `json:",string"`
 }
@@ -0,0 +1,165 @@
+/*
+Package sdk implements a typed client of the bnsapi REST API.
+
+Lists are returned as iterators that fetch consecutive pages on demand, so
+that the pagination of the API is not exposed to the caller.
+*/
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/x/cash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Client is a bnsapi REST API client.
+type Client struct {
+	apiURL string
+	cli    *http.Client
+}
+
+// NewClient returns a client of the bnsapi instance available under given
+// URL, ie https://bnsapi.iov.one. If cli is nil, http.DefaultClient is used.
+func NewClient(apiURL string, cli *http.Client) *Client {
+	if cli == nil {
+		cli = http.DefaultClient
+	}
+	return &Client{
+		apiURL: strings.TrimRight(apiURL, "/"),
+		cli:    cli,
+	}
+}
+
+// Resolve returns the account of given starname, ie alice*neuma.
+func (c *Client) Resolve(ctx context.Context, starname string) (*account.Account, error) {
+	var acc account.Account
+	if err := c.get(ctx, "/account/resolve/"+url.PathEscape(starname), nil, &acc); err != nil {
+		return nil, err
+	}
+	return &acc, nil
+}
+
+// Balance returns the funds that given address holds. An address without a
+// wallet holds no funds.
+func (c *Client) Balance(ctx context.Context, addr weave.Address) (*cash.Set, error) {
+	var set cash.Set
+	switch err := c.get(ctx, "/cash/balances", url.Values{"address": {addr.String()}}, &set); {
+	case err == nil:
+		return &set, nil
+	case errors.ErrNotFound.Is(err):
+		return &cash.Set{}, nil
+	default:
+		return nil, err
+	}
+}
+
+// Username returns the username token of given name, ie alice*iov.
+func (c *Client) Username(ctx context.Context, name string) (*username.Token, error) {
+	var res struct {
+		Model username.Token `json:"model"`
+	}
+	if err := c.get(ctx, "/username/resolve/"+url.PathEscape(name), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res.Model, nil
+}
+
+// AccountFilter limits listed accounts. At most one filter can be set.
+type AccountFilter struct {
+	// Domain lists only the accounts of given domain, ie neuma.
+	Domain string
+	// Owner lists only the accounts owned by given address.
+	Owner weave.Address
+}
+
+// Accounts returns an iterator over all accounts matching the filter. Next
+// must be called with an *account.Account.
+func (c *Client) Accounts(ctx context.Context, filter AccountFilter) Iterator {
+	q := url.Values{}
+	if filter.Domain != "" {
+		q.Set("domain", filter.Domain)
+	}
+	if filter.Owner != nil {
+		q.Set("owner", filter.Owner.String())
+	}
+	return &pageIterator{
+		ctx:    ctx,
+		c:      c,
+		path:   "/account/accounts",
+		query:  q,
+		offset: keyOffset,
+	}
+}
+
+// Usernames returns an iterator over all username tokens, or only the tokens
+// of given owner if not nil. Next must be called with a *username.Token.
+func (c *Client) Usernames(ctx context.Context, owner weave.Address) Iterator {
+	q := url.Values{}
+	if owner != nil {
+		q.Set("owner", owner.String())
+	}
+	return &pageIterator{
+		ctx:   ctx,
+		c:     c,
+		path:  "/username/usernames",
+		query: q,
+	}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, dest interface{}) error {
+	u := c.apiURL + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return errors.Wrap(err, "create http request")
+	}
+	req = req.WithContext(ctx)
+
+	resp, err := c.cli.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "do request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return responseError(resp)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return errors.Wrap(err, "decode response")
+	}
+	return nil
+}
+
+// responseError returns an error for an API error response. 404 and 400
+// responses are reported as errors.ErrNotFound and errors.ErrInput.
+func responseError(resp *http.Response) error {
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1e5))
+	msg := string(b)
+	var payload struct {
+		Errors []string `json:"errors"`
+	}
+	if err := json.Unmarshal(b, &payload); err == nil && len(payload.Errors) != 0 {
+		msg = strings.Join(payload.Errors, ", ")
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return errors.Wrap(errors.ErrNotFound, msg)
+	case http.StatusBadRequest:
+		return errors.Wrap(errors.ErrInput, msg)
+	default:
+		return errors.Wrapf(errors.ErrNetwork, "bad response: %d %s", resp.StatusCode, msg)
+	}
+}
@@ -0,0 +1,181 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/util"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/coin"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/x/cash"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestClientResolve(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/account/resolve/alice*neuma":
+			writeJSON(t, w, http.StatusOK, account.Account{Name: "alice", Domain: "neuma"})
+		default:
+			writeJSON(t, w, http.StatusNotFound, map[string][]string{"errors": {"Not Found"}})
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, nil)
+
+	acc, err := c.Resolve(context.Background(), "alice*neuma")
+	if err != nil {
+		t.Fatalf("resolve: %s", err)
+	}
+	if acc.Name != "alice" || acc.Domain != "neuma" {
+		t.Fatalf("unexpected account: %+v", acc)
+	}
+
+	if _, err := c.Resolve(context.Background(), "bob*neuma"); !errors.ErrNotFound.Is(err) {
+		t.Fatalf("want not found error, got %v", err)
+	}
+}
+
+func TestClientBalance(t *testing.T) {
+	rich := weave.NewAddress([]byte("rich"))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("address") == rich.String() {
+			writeJSON(t, w, http.StatusOK, cash.Set{Coins: []*coin.Coin{coin.NewCoinp(5, 0, "IOV")}})
+			return
+		}
+		writeJSON(t, w, http.StatusNotFound, map[string][]string{"errors": {"Not Found"}})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, nil)
+
+	set, err := c.Balance(context.Background(), rich)
+	if err != nil {
+		t.Fatalf("balance: %s", err)
+	}
+	if len(set.Coins) != 1 || !set.Coins[0].Equals(coin.NewCoin(5, 0, "IOV")) {
+		t.Fatalf("unexpected balance: %v", set.Coins)
+	}
+
+	set, err = c.Balance(context.Background(), weave.NewAddress([]byte("poor")))
+	if err != nil {
+		t.Fatalf("balance: %s", err)
+	}
+	if len(set.Coins) != 0 {
+		t.Fatalf("want no funds, got %v", set.Coins)
+	}
+}
+
+func TestClientAccountsPagination(t *testing.T) {
+	// Pages are short, as returned by a node that limits range queries to
+	// 50 entities. Each page starts with the last account of the previous
+	// one.
+	const (
+		total    = 120
+		pageSize = 50
+	)
+	name := func(i int) string { return fmt.Sprintf("a%05d", i) }
+	var offsets []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/account/accounts" || r.URL.Query().Get("domain") != "neuma" {
+			t.Fatalf("unexpected request: %s", r.URL)
+		}
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+
+		start := 0
+		if offset != "" {
+			fmt.Sscanf(offset, "a%05d*neuma", &start)
+		}
+		objects := make([]util.KeyValue, 0, pageSize)
+		for i := start; i < total && len(objects) < pageSize; i++ {
+			objects = append(objects, util.KeyValue{
+				Key:   []byte("account:" + name(i) + "*neuma"),
+				Value: &account.Account{Name: name(i), Domain: "neuma"},
+			})
+		}
+		writeJSON(t, w, http.StatusOK, map[string]interface{}{"objects": objects})
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, nil)
+
+	it := c.Accounts(context.Background(), AccountFilter{Domain: "neuma"})
+	for i := 0; ; i++ {
+		var acc account.Account
+		_, err := it.Next(&acc)
+		if errors.ErrIteratorDone.Is(err) {
+			if i != total {
+				t.Fatalf("want %d accounts, got %d", total, i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %s", err)
+		}
+		if acc.Name != name(i) {
+			t.Fatalf("want account %q at %d, got %q", name(i), i, acc.Name)
+		}
+	}
+
+	// The last page contains only the account that it was requested
+	// with, which ends the iteration.
+	wantOffsets := []string{"", name(49) + "*neuma", name(98) + "*neuma", name(119) + "*neuma"}
+	if !reflect.DeepEqual(offsets, wantOffsets) {
+		t.Fatalf("want %q requested offsets, got %q", wantOffsets, offsets)
+	}
+}
+
+func TestClientUsernamesNextOffset(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch offset := r.URL.Query().Get("offset"); offset {
+		case "":
+			writeJSON(t, w, http.StatusOK, map[string]interface{}{
+				"objects": []util.KeyValue{
+					{Key: []byte("tokens:alice*iov"), Value: &username.Token{}},
+				},
+				"next_offset": "bob*iov",
+			})
+		case "bob*iov":
+			writeJSON(t, w, http.StatusOK, map[string]interface{}{
+				"objects": []util.KeyValue{
+					{Key: []byte("tokens:bob*iov"), Value: &username.Token{}},
+				},
+			})
+		default:
+			t.Fatalf("unexpected offset: %q", offset)
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL, nil)
+
+	var keys []string
+	it := c.Usernames(context.Background(), nil)
+	for {
+		var token username.Token
+		key, err := it.Next(&token)
+		if errors.ErrIteratorDone.Is(err) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("next: %s", err)
+		}
+		keys = append(keys, string(key))
+	}
+	if len(keys) != 2 || keys[0] != "tokens:alice*iov" || keys[1] != "tokens:bob*iov" {
+		t.Fatalf("unexpected keys: %q", keys)
+	}
+}
+
+func writeJSON(t testing.TB, w http.ResponseWriter, code int, payload interface{}) {
+	t.Helper()
+	w.Header().Set("content-type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		t.Fatalf("cannot write response: %s", err)
+	}
+}
@@ -0,0 +1,131 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	"net/url"
+)
+
+// Iterator iterates over a list of entities returned by the API. Next
+// decodes the next entity into given model and returns its key. When there
+// are no more entities, errors.ErrIteratorDone is returned.
+type Iterator interface {
+	Next(orm.Model) ([]byte, error)
+}
+
+// pageIterator fetches consecutive pages of a MultipleObjectsResponse. The
+// next page is requested with the offset returned by the API or, if the API
+// does not provide one, with the offset computed from the last key of the
+// page. Page size depends on the node, so pages are fetched until one
+// contains no new entities.
+type pageIterator struct {
+	ctx   context.Context
+	c     *Client
+	path  string
+	query url.Values
+	// offset returns the offset to request the page following the given
+	// key with. The page starts with that key. Nil if the API does not
+	// accept key offsets.
+	offset func(key []byte) string
+
+	objects []object
+	lastKey []byte
+	next    string
+	// nextIsKey is true if next was computed from the last key.
+	nextIsKey bool
+	fetched   bool
+	err       error
+}
+
+type object struct {
+	Key   hexKey          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type page struct {
+	Objects    []object `json:"objects"`
+	NextOffset string   `json:"next_offset"`
+}
+
+func (it *pageIterator) Next(dest orm.Model) ([]byte, error) {
+	if it.err != nil {
+		return nil, it.err
+	}
+	for len(it.objects) == 0 {
+		if it.fetched && it.next == "" {
+			return nil, errors.ErrIteratorDone
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return nil, err
+		}
+	}
+
+	obj := it.objects[0]
+	it.objects = it.objects[1:]
+	if err := json.Unmarshal(obj.Value, dest); err != nil {
+		return nil, errors.Wrap(err, "decode entity")
+	}
+	it.lastKey = obj.Key
+	return obj.Key, nil
+}
+
+func (it *pageIterator) fetch() error {
+	q := url.Values{}
+	for k, v := range it.query {
+		q[k] = v
+	}
+	if it.next != "" {
+		q.Set("offset", it.next)
+	}
+
+	var p page
+	if err := it.c.get(it.ctx, it.path, q, &p); err != nil {
+		return err
+	}
+	it.fetched = true
+
+	objects := p.Objects
+	// A page requested with a key offset starts with the last key of the
+	// previous page.
+	if it.nextIsKey && len(objects) != 0 && bytes.Equal(objects[0].Key, it.lastKey) {
+		objects = objects[1:]
+	}
+	it.objects = objects
+
+	switch {
+	case p.NextOffset != "":
+		it.next, it.nextIsKey = p.NextOffset, false
+	case it.offset != nil && len(objects) != 0:
+		it.next, it.nextIsKey = it.offset(objects[len(objects)-1].Key), true
+	default:
+		it.next, it.nextIsKey = "", false
+	}
+	return nil
+}
+
+// keyOffset returns the key without the bucket prefix, being the characters
+// before : (including separator).
+func keyOffset(key []byte) string {
+	return string(key[bytes.Index(key, []byte(":"))+1:])
+}
+
+// hexKey is a key that is JSON serialized as a hex encoded string.
+type hexKey []byte
+
+func (k *hexKey) UnmarshalJSON(enc []byte) error {
+	var s string
+	if err := json.Unmarshal(enc, &s); err != nil {
+		return err
+	}
+	val, err := hex.DecodeString(s)
+	if err != nil {
+		return err
+	}
+	*k = val
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"github.com/iov-one/bns/cmd/bnsapi/handlers"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/orm"
+	"github.com/iov-one/weave/x/cash"
+	"strconv"
+	"strings"
+)
+
+// result is a single entity returned by a query.
+type result struct {
+	Key   []byte
+	Value orm.Model
+}
+
+type command func(ctx context.Context, bns client.BnsClient, args []string, limit int) ([]result, error)
+
+var commands = map[string]command{
+	"accounts": cmdAccounts,
+	"domains":  cmdDomains,
+	"resolve":  cmdResolve,
+	"username": cmdUsername,
+	"balance":  cmdBalance,
+	"raw":      cmdRaw,
+}
+
+func cmdAccounts(ctx context.Context, bns client.BnsClient, args []string, limit int) ([]result, error) {
+	fl := flag.NewFlagSet("accounts", flag.ContinueOnError)
+	var (
+		domain = fl.String("domain", "", "List only accounts of given domain.")
+		owner  = fl.String("owner", "", "List only accounts of given owner address.")
+	)
+	if err := fl.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var it client.ABCIIterator
+	switch {
+	case *domain != "" && *owner != "":
+		return nil, errors.Wrap(errors.ErrInput, "at most one filter can be used at a time")
+	case *domain != "":
+		it = client.ABCIIndexRangeQuery(ctx, bns, "/accounts/domain", []byte(*domain))
+	case *owner != "":
+		rawAddr, err := handlers.WeaveAddressFromQuery(*owner)
+		if err != nil {
+			return nil, errors.Wrap(err, "owner")
+		}
+		it = client.ABCIIndexRangeQuery(ctx, bns, "/accounts/owner", rawAddr)
+	default:
+		it = client.ABCIFullRangeQuery(ctx, bns, "/accounts", "")
+	}
+	return collect(it, func() orm.Model { return &account.Account{} }, limit)
+}
+
+func cmdDomains(ctx context.Context, bns client.BnsClient, args []string, limit int) ([]result, error) {
+	fl := flag.NewFlagSet("domains", flag.ContinueOnError)
+	admin := fl.String("admin", "", "List only domains of given admin address.")
+	if err := fl.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var it client.ABCIIterator
+	if *admin != "" {
+		rawAddr, err := handlers.WeaveAddressFromQuery(*admin)
+		if err != nil {
+			return nil, errors.Wrap(err, "admin")
+		}
+		it = client.ABCIIndexRangeQuery(ctx, bns, "/domains/admin", rawAddr)
+	} else {
+		it = client.ABCIFullRangeQuery(ctx, bns, "/domains", "")
+	}
+	return collect(it, func() orm.Model { return &account.Domain{} }, limit)
+}
+
+func cmdResolve(ctx context.Context, bns client.BnsClient, args []string, limit int) ([]result, error) {
+	if len(args) != 1 {
+		return nil, errors.Wrap(errors.ErrInput, "starname argument required")
+	}
+	return keyQuery(ctx, bns, "/accounts", []byte(args[0]), &account.Account{})
+}
+
+func cmdUsername(ctx context.Context, bns client.BnsClient, args []string, limit int) ([]result, error) {
+	if len(args) != 1 {
+		return nil, errors.Wrap(errors.ErrInput, "username argument required")
+	}
+	name := args[0]
+	if !strings.Contains(name, "*") {
+		name += "*iov"
+	}
+	return keyQuery(ctx, bns, "/usernames", []byte(name), &username.Token{})
+}
+
+func cmdBalance(ctx context.Context, bns client.BnsClient, args []string, limit int) ([]result, error) {
+	if len(args) != 1 {
+		return nil, errors.Wrap(errors.ErrInput, "address argument required")
+	}
+	addr, err := handlers.WeaveAddressFromQuery(args[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "address")
+	}
+	return keyQuery(ctx, bns, "/wallets", addr, &cash.Set{})
+}
+
+func cmdRaw(ctx context.Context, bns client.BnsClient, args []string, limit int) ([]result, error) {
+	fl := flag.NewFlagSet("raw", flag.ContinueOnError)
+	keyFormat := fl.String("key", "auto", "Key format: auto, string, hex, address or id.")
+	if err := fl.Parse(args); err != nil {
+		return nil, err
+	}
+	if fl.NArg() < 1 || fl.NArg() > 2 {
+		return nil, errors.Wrap(errors.ErrInput, "path and an optional key arguments required")
+	}
+	path := fl.Arg(0)
+	if !strings.HasPrefix(path, "/") {
+		return nil, errors.Wrapf(errors.ErrInput, "path must start with /, ie /%s", path)
+	}
+	newModel := modelOf(path)
+
+	if fl.NArg() == 1 {
+		if strings.Contains(path[1:], "/") {
+			// Index range queries are paged by the indexed value,
+			// so an index cannot be listed without one.
+			return nil, errors.Wrapf(errors.ErrInput, "index path %s requires a key", path)
+		}
+		return collect(client.ABCIFullRangeQuery(ctx, bns, path, ""), newModel, limit)
+	}
+	key, err := parseKey(*keyFormat, fl.Arg(1))
+	if err != nil {
+		return nil, errors.Wrap(err, "key")
+	}
+	// An index can point to many entities.
+	results, err := collect(client.ABCIKeyQueryIter(ctx, bns, path, key), newModel, limit)
+	if errors.ErrNotFound.Is(err) {
+		return nil, nil
+	}
+	return results, err
+}
+
+// parseKey decodes a key given in the command line. Auto format guesses the
+// key type, trying in order a numeric sequence ID, an address and falling
+// back to the key as is.
+func parseKey(format, raw string) ([]byte, error) {
+	switch format {
+	case "string":
+		return []byte(raw), nil
+	case "hex":
+		return hex.DecodeString(raw)
+	case "address":
+		return handlers.WeaveAddressFromQuery(raw)
+	case "id":
+		return handlers.ExtractNumericID(raw)
+	case "auto":
+		if _, err := strconv.ParseUint(raw, 10, 64); err == nil {
+			return handlers.ExtractNumericID(raw)
+		}
+		if addr, err := handlers.WeaveAddressFromQuery(raw); err == nil && addr.Validate() == nil {
+			return addr, nil
+		}
+		return []byte(raw), nil
+	default:
+		return nil, errors.Wrapf(errors.ErrInput, "unknown key format %q", format)
+	}
+}
+
+func keyQuery(ctx context.Context, bns client.BnsClient, path string, key []byte, model orm.Model) ([]result, error) {
+	res := models.KeyModel{Model: model}
+	switch err := client.ABCIKeyQuery(ctx, bns, path, key, &res); {
+	case err == nil:
+		return []result{{Key: res.Key, Value: model}}, nil
+	case errors.ErrNotFound.Is(err):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// collect reads all entities from the iterator, up to the limit if greater
+// than zero.
+func collect(it client.ABCIIterator, newModel func() orm.Model, limit int) ([]result, error) {
+	var results []result
+	for limit <= 0 || len(results) < limit {
+		m := newModel()
+		switch key, err := it.Next(m); {
+		case err == nil:
+			results = append(results, result{Key: key, Value: m})
+		case errors.ErrIteratorDone.Is(err):
+			return results, nil
+		default:
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,89 @@
+/*
+bnsquery queries the state of a BNS blockchain the same way bnsapi does, but
+from the command line and without running the server.
+
+	bnsquery [-tendermint url] [-format json|table|csv] [-limit n] <command> [args]
+
+Available commands are:
+
+	accounts [-domain name | -owner address]  List accounts.
+	domains [-admin address]                  List domains.
+	resolve <starname>                        Show account of a starname, ie alice*neuma.
+	username <name>                           Show username token, ie alice*iov.
+	balance <address>                         Show wallet of an address.
+	raw <path> [key]                          Query any bucket or index, ie /escrows/source <address>.
+	                                          Without a key, the whole bucket is listed.
+	                                          An index always requires a key.
+*/
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/client"
+	"io"
+	"os"
+	"time"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "bnsquery: %s\n", err)
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	fl := flag.NewFlagSet("bnsquery", flag.ContinueOnError)
+	fl.SetOutput(stderr)
+	fl.Usage = func() {
+		fmt.Fprintln(stderr, `Usage: bnsquery [flags] <command> [args]
+
+Commands: accounts, domains, resolve, username, balance, raw.
+Run bnsquery <command> -h for the command flags.
+
+Flags:`)
+		fl.PrintDefaults()
+	}
+	var (
+		tmURL   = fl.String("tendermint", env("TENDERMINT", "http://localhost:26657"), "Tendermint RPC address.")
+		format  = fl.String("format", "json", "Output format: json, table or csv.")
+		limit   = fl.Int("limit", 0, "Maximum number of results. Zero means no limit.")
+		timeout = fl.Duration("timeout", time.Minute, "Timeout of the whole query.")
+	)
+	if err := fl.Parse(args); err != nil {
+		return err
+	}
+	if fl.NArg() == 0 {
+		fl.Usage()
+		return fmt.Errorf("command required")
+	}
+
+	write, ok := writers[*format]
+	if !ok {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	cmd, ok := commands[fl.Arg(0)]
+	if !ok {
+		fl.Usage()
+		return fmt.Errorf("unknown command %q", fl.Arg(0))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	bns := client.NewHTTPBnsClient(*tmURL)
+	results, err := cmd(ctx, bns, fl.Args()[1:], *limit)
+	if err != nil {
+		return fmt.Errorf("%s: %s", fl.Arg(0), err)
+	}
+	return write(stdout, results)
+}
+
+func env(name, fallback string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"github.com/iov-one/bns/cmd/bnsapi/bnsapitest"
+	"github.com/iov-one/bns/cmd/bnsapi/models"
+	"github.com/iov-one/weave"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/errors"
+	"github.com/iov-one/weave/x/escrow"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	hexKey := func(b []byte) string { return strings.ToUpper(hex.EncodeToString(b)) }
+	source := weave.NewAddress([]byte("source"))
+	domainEnd := []byte("neumb")
+
+	bns := &bnsapitest.BnsClientMock{
+		PostResults: map[string]map[string]models.AbciQueryResponse{
+			"/accounts": {
+				hexKey([]byte("alice*neuma")): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("alice*neuma")},
+					[]weave.Persistent{&account.Account{Name: "alice", Domain: "neuma"}}),
+			},
+			"/accounts/domain?range": {
+				hexKey([]byte(fmt.Sprintf("%x::%x", "neuma", domainEnd))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("alice*neuma"), []byte("bob*neuma")},
+					[]weave.Persistent{
+						&account.Account{Name: "alice", Domain: "neuma"},
+						&account.Account{Name: "bob", Domain: "neuma"},
+					}),
+				// Index range query is continued from the last returned key.
+				hexKey([]byte(fmt.Sprintf("%x:%x:%x", "neuma", "bob*neuma", domainEnd))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("bob*neuma"), []byte("charlie*neuma")},
+					[]weave.Persistent{
+						&account.Account{Name: "bob", Domain: "neuma"},
+						&account.Account{Name: "charlie", Domain: "neuma"},
+					}),
+				hexKey([]byte(fmt.Sprintf("%x:%x:%x", "neuma", "charlie*neuma", domainEnd))): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("charlie*neuma")},
+					[]weave.Persistent{&account.Account{Name: "charlie", Domain: "neuma"}}),
+			},
+			"/escrows/source": {
+				hexKey(source): bnsapitest.NewAbciQueryResponse(t,
+					[][]byte{[]byte("esc:1")},
+					[]weave.Persistent{&escrow.Escrow{Source: source}}),
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		cmd      command
+		args     []string
+		limit    int
+		wantKeys []string
+	}{
+		"resolve": {
+			cmd:      cmdResolve,
+			args:     []string{"alice*neuma"},
+			wantKeys: []string{"alice*neuma"},
+		},
+		"accounts of a domain": {
+			cmd:      cmdAccounts,
+			args:     []string{"-domain", "neuma"},
+			wantKeys: []string{"alice*neuma", "bob*neuma", "charlie*neuma"},
+		},
+		"accounts with limit": {
+			cmd:      cmdAccounts,
+			args:     []string{"-domain", "neuma"},
+			limit:    1,
+			wantKeys: []string{"alice*neuma"},
+		},
+		"raw index query": {
+			cmd:      cmdRaw,
+			args:     []string{"/escrows/source", source.String()},
+			wantKeys: []string{"esc:1"},
+		},
+	}
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			results, err := tc.cmd(context.Background(), bns, tc.args, tc.limit)
+			if err != nil {
+				t.Fatalf("command: %s", err)
+			}
+			var keys []string
+			for _, r := range results {
+				keys = append(keys, string(r.Key))
+			}
+			if strings.Join(keys, ",") != strings.Join(tc.wantKeys, ",") {
+				t.Fatalf("want %q keys, got %q", tc.wantKeys, keys)
+			}
+		})
+	}
+}
+
+func TestRawIndexRequiresKey(t *testing.T) {
+	_, err := cmdRaw(context.Background(), &bnsapitest.BnsClientMock{}, []string{"/escrows/source"}, 0)
+	if !errors.ErrInput.Is(err) {
+		t.Fatalf("want input error, got %v", err)
+	}
+}
+
+func TestModelOf(t *testing.T) {
+	if _, ok := modelOf("/escrows/source")().(*escrow.Escrow); !ok {
+		t.Fatal("index must return the model of its bucket")
+	}
+	if _, ok := modelOf("/unknown")().(*rawModel); !ok {
+		t.Fatal("unknown bucket must return a raw model")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	results := []result{
+		{Key: []byte("alice*neuma"), Value: &account.Account{Name: "alice", Domain: "neuma"}},
+		{Key: []byte{0, 1}, Value: &rawModel{Raw: []byte{0xff}}},
+	}
+	var b bytes.Buffer
+	if err := writeCSV(&b, results); err != nil {
+		t.Fatalf("write: %s", err)
+	}
+	want := `key,domain,name,targets,value
+alice*neuma,neuma,alice,,
+0001,,,,ff
+`
+	if got := b.String(); got != want {
+		t.Fatalf("unexpected CSV:\n%s", got)
+	}
+}
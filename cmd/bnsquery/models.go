@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"github.com/iov-one/weave/cmd/bnsd/x/account"
+	"github.com/iov-one/weave/cmd/bnsd/x/preregistration"
+	"github.com/iov-one/weave/cmd/bnsd/x/termdeposit"
+	"github.com/iov-one/weave/cmd/bnsd/x/username"
+	"github.com/iov-one/weave/migration"
+	"github.com/iov-one/weave/orm"
+	"github.com/iov-one/weave/x/cash"
+	"github.com/iov-one/weave/x/currency"
+	"github.com/iov-one/weave/x/escrow"
+	"github.com/iov-one/weave/x/gov"
+	"github.com/iov-one/weave/x/msgfee"
+	"github.com/iov-one/weave/x/multisig"
+	"github.com/iov-one/weave/x/sigs"
+	"strings"
+)
+
+// bucketModels maps query paths of buckets to the model they store. Index
+// query paths (ie /escrows/source) return the model of their bucket.
+var bucketModels = map[string]func() orm.Model{
+	"/accounts":               func() orm.Model { return &account.Account{} },
+	"/auth":                   func() orm.Model { return &sigs.UserData{} },
+	"/contracts":              func() orm.Model { return &multisig.Contract{} },
+	"/depositcontracts":       func() orm.Model { return &termdeposit.DepositContract{} },
+	"/deposits":               func() orm.Model { return &termdeposit.Deposit{} },
+	"/domains":                func() orm.Model { return &account.Domain{} },
+	"/electionrules":          func() orm.Model { return &gov.ElectionRule{} },
+	"/electorates":            func() orm.Model { return &gov.Electorate{} },
+	"/escrows":                func() orm.Model { return &escrow.Escrow{} },
+	"/msgfee":                 func() orm.Model { return &msgfee.MsgFee{} },
+	"/preregistrationrecords": func() orm.Model { return &preregistration.Record{} },
+	"/proposals":              func() orm.Model { return &gov.Proposal{} },
+	"/schemas":                func() orm.Model { return &migration.Schema{} },
+	"/tokens":                 func() orm.Model { return &currency.TokenInfo{} },
+	"/usernames":              func() orm.Model { return &username.Token{} },
+	"/votes":                  func() orm.Model { return &gov.Vote{} },
+	"/wallets":                func() orm.Model { return &cash.Set{} },
+}
+
+// modelOf returns the model constructor for given query path. Entities of
+// unknown buckets are returned as raw bytes.
+func modelOf(path string) func() orm.Model {
+	bucket := path
+	if i := strings.Index(strings.TrimPrefix(path, "/"), "/"); i >= 0 {
+		bucket = path[:i+1]
+	}
+	if fn, ok := bucketModels[bucket]; ok {
+		return fn
+	}
+	return func() orm.Model { return &rawModel{} }
+}
+
+// rawModel is a model of an unknown type. It is serialized as hex encoded
+// bytes.
+type rawModel struct {
+	Raw []byte
+}
+
+func (m *rawModel) Marshal() ([]byte, error) {
+	return m.Raw, nil
+}
+
+func (m *rawModel) Unmarshal(raw []byte) error {
+	m.Raw = append([]byte(nil), raw...)
+	return nil
+}
+
+func (m *rawModel) Validate() error {
+	return nil
+}
+
+func (m *rawModel) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(m.Raw))
+}
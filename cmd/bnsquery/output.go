@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"unicode"
+)
+
+type writer func(w io.Writer, results []result) error
+
+var writers = map[string]writer{
+	"json":  writeJSON,
+	"table": writeTable,
+	"csv":   writeCSV,
+}
+
+type jsonResult struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+}
+
+func writeJSON(w io.Writer, results []result) error {
+	out := make([]jsonResult, 0, len(results))
+	for _, r := range results {
+		out = append(out, jsonResult{Key: displayKey(r.Key), Value: r.Value})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(out)
+}
+
+func writeTable(w io.Writer, results []result) error {
+	header, rows, err := tabulate(results)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, results []result) error {
+	header, rows, err := tabulate(results)
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+// tabulate flattens the results into rows of the key and the top level
+// fields of the JSON representation of each value. Nested values are
+// rendered as compact JSON.
+func tabulate(results []result) ([]string, [][]string, error) {
+	values := make([]map[string]json.RawMessage, 0, len(results))
+	columns := make(map[string]struct{})
+	for _, r := range results {
+		raw, err := json.Marshal(r.Value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("cannot serialize %s: %s", displayKey(r.Key), err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			// Not an object, ie a raw model.
+			fields = map[string]json.RawMessage{"value": raw}
+		}
+		for name := range fields {
+			columns[name] = struct{}{}
+		}
+		values = append(values, fields)
+	}
+
+	header := make([]string, 0, len(columns)+1)
+	for name := range columns {
+		header = append(header, name)
+	}
+	sort.Strings(header)
+	header = append([]string{"key"}, header...)
+
+	rows := make([][]string, 0, len(results))
+	for i, r := range results {
+		row := []string{displayKey(r.Key)}
+		for _, name := range header[1:] {
+			row = append(row, cell(values[i][name]))
+		}
+		rows = append(rows, row)
+	}
+	return header, rows, nil
+}
+
+func cell(raw json.RawMessage) string {
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return string(raw)
+}
+
+// displayKey returns the key as a string if it is printable or hex encoded
+// otherwise.
+func displayKey(key []byte) string {
+	for _, r := range string(key) {
+		if r == unicode.ReplacementChar || !unicode.IsPrint(r) {
+			return hex.EncodeToString(key)
+		}
+	}
+	return string(key)
+}